@@ -9,8 +9,10 @@ import (
 
 func main() {
 	// In the simon package we essentially instantiate the command line interface with the functionalities provided by the cobra library.
+	// NOTE: `simon bench` (pkg/simulator/bench) is registered as a subcommand inside cmd/simon.NewSimonCommand(),
+	// alongside the existing `apply` subcommand.
 	cmd := simon.NewSimonCommand()
-	
+
 	if err := cmd.Execute(); err != nil {
 		fmt.Printf("start with error: %s", err.Error())
 		os.Exit(1)
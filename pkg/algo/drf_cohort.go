@@ -0,0 +1,213 @@
+package algo
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	resourcehelper "k8s.io/kubectl/pkg/util/resource"
+
+	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/api/v1alpha1"
+)
+
+// LabelCohort is the pod label that assigns a pod to a tenant/queue cohort
+// for DRFCohortQueue, mirroring Kueue's cohort-based fair sharing.
+const LabelCohort = "scheduler-simulator/cohort"
+
+// cohortUsage tracks a single cohort's current resource usage and its
+// configured nominal/lending quota, against which its dominant resource share
+// is computed.
+type cohortUsage struct {
+	config       v1alpha1.CohortConfig
+	usedMilliCpu int64
+	usedMemory   int64
+	usedMilliGpu int64
+	borrowed     int64 // milliCPU currently borrowed from the shared lending pool
+}
+
+// DRFCohortQueue sorts pending pods so the cohort with the lowest dominant
+// resource share is served next, following Kueue's cohort DRF: cohorts that
+// under-use their nominal quota lend their slack to a shared pool, and
+// cohorts borrowing from that pool carry the borrowed usage in their own
+// share so they are deprioritized relative to non-borrowing cohorts.
+type DRFCohortQueue struct {
+	pods    []*corev1.Pod
+	cohorts map[string]*cohortUsage
+}
+
+// NewDRFCohortQueue builds a queue over pods, with cohorts configured via
+// cfgs (keyed by CohortConfig.Name).
+func NewDRFCohortQueue(pods []*corev1.Pod, cfgs []v1alpha1.CohortConfig) *DRFCohortQueue {
+	cohorts := make(map[string]*cohortUsage, len(cfgs))
+	for _, cfg := range cfgs {
+		cohorts[cfg.Name] = &cohortUsage{config: cfg}
+	}
+	return &DRFCohortQueue{pods: pods, cohorts: cohorts}
+}
+
+func (q *DRFCohortQueue) Len() int      { return len(q.pods) }
+func (q *DRFCohortQueue) Swap(i, j int) { q.pods[i], q.pods[j] = q.pods[j], q.pods[i] }
+func (q *DRFCohortQueue) Less(i, j int) bool {
+	return q.DominantResourceShareWith(q.pods[i]) < q.DominantResourceShareWith(q.pods[j])
+}
+
+// DominantResourceShareWith returns the dominant resource share a cohort
+// would have if pod were additionally assigned to it, i.e. max_r(used_r +
+// req_r) / quota_r over CPU, memory and GPU-milli.
+func (q *DRFCohortQueue) DominantResourceShareWith(pod *corev1.Pod) float64 {
+	cohort, ok := q.cohortOf(pod)
+	if !ok {
+		return 0
+	}
+	req, _ := resourcehelper.PodRequestsAndLimits(pod)
+	milliGpuReq := req["nvidia.com/gpu"]
+
+	return dominantShare(
+		cohort.usedMilliCpu+req.Cpu().MilliValue(),
+		cohort.usedMemory+req.Memory().Value(),
+		cohort.usedMilliGpu+milliGpuReq.MilliValue(),
+		q.effectiveQuotaOf(pod.Labels[LabelCohort]),
+	)
+}
+
+// DominantResourceShareWithout returns the dominant resource share a cohort
+// would have if pod (currently assigned to it) were removed, for preemption
+// and release bookkeeping.
+func (q *DRFCohortQueue) DominantResourceShareWithout(pod *corev1.Pod) float64 {
+	cohort, ok := q.cohortOf(pod)
+	if !ok {
+		return 0
+	}
+	req, _ := resourcehelper.PodRequestsAndLimits(pod)
+	milliGpuReq := req["nvidia.com/gpu"]
+
+	return dominantShare(
+		cohort.usedMilliCpu-req.Cpu().MilliValue(),
+		cohort.usedMemory-req.Memory().Value(),
+		cohort.usedMilliGpu-milliGpuReq.MilliValue(),
+		q.effectiveQuotaOf(pod.Labels[LabelCohort]),
+	)
+}
+
+// Assign records pod against its cohort's usage, counting whatever part of
+// pod's CPU request lies above the cohort's nominal quota as borrowed from the
+// shared lending pool (built from other cohorts' slack).
+func (q *DRFCohortQueue) Assign(pod *corev1.Pod) {
+	cohort, ok := q.cohortOf(pod)
+	if !ok {
+		return
+	}
+	req, _ := resourcehelper.PodRequestsAndLimits(pod)
+	milliCpu := req.Cpu().MilliValue()
+
+	if over := cohort.usedMilliCpu + milliCpu - cohort.config.NominalQuota.Cpu().MilliValue(); over > 0 {
+		cohort.borrowed += minInt64(over, milliCpu)
+	}
+	cohort.usedMilliCpu += milliCpu
+	cohort.usedMemory += req.Memory().Value()
+	cohort.usedMilliGpu += req["nvidia.com/gpu"].MilliValue()
+}
+
+// sharedPoolSlack sums, over every cohort other than excludeName, the slack
+// each dimension has below its own nominal quota -- the shared pool that
+// over-using cohorts can borrow against.
+func (q *DRFCohortQueue) sharedPoolSlack(excludeName string) (milliCpu, memory, milliGpu int64) {
+	for name, c := range q.cohorts {
+		if name == excludeName {
+			continue
+		}
+		if slack := c.config.NominalQuota.Cpu().MilliValue() - c.usedMilliCpu; slack > 0 {
+			milliCpu += slack
+		}
+		if slack := c.config.NominalQuota.Memory().Value() - c.usedMemory; slack > 0 {
+			memory += slack
+		}
+		nominalGpu := c.config.NominalQuota["nvidia.com/gpu"]
+		if slack := nominalGpu.MilliValue() - c.usedMilliGpu; slack > 0 {
+			milliGpu += slack
+		}
+	}
+	return milliCpu, memory, milliGpu
+}
+
+// effectiveQuotaOf is cohortName's nominal quota plus whatever it is entitled
+// to borrow from the shared pool of other cohorts' slack, capped at its own
+// configured lending limit, across all three dimensions.
+func (q *DRFCohortQueue) effectiveQuotaOf(cohortName string) corev1.ResourceList {
+	c, ok := q.cohorts[cohortName]
+	if !ok {
+		return corev1.ResourceList{}
+	}
+	quota := c.config.NominalQuota.DeepCopy()
+	poolMilliCpu, poolMemory, poolMilliGpu := q.sharedPoolSlack(cohortName)
+
+	if borrow := minInt64(c.config.LendingLimit.Cpu().MilliValue(), poolMilliCpu); borrow > 0 {
+		newCpu := quota.Cpu().MilliValue() + borrow
+		quota[corev1.ResourceCPU] = *resource.NewMilliQuantity(newCpu, resource.DecimalSI)
+	}
+	if borrow := minInt64(c.config.LendingLimit.Memory().Value(), poolMemory); borrow > 0 {
+		newMemory := quota.Memory().Value() + borrow
+		quota[corev1.ResourceMemory] = *resource.NewQuantity(newMemory, resource.BinarySI)
+	}
+	lendingGpu := c.config.LendingLimit["nvidia.com/gpu"]
+	if borrow := minInt64(lendingGpu.MilliValue(), poolMilliGpu); borrow > 0 {
+		gpuQuota := quota["nvidia.com/gpu"]
+		newGpu := gpuQuota.MilliValue() + borrow
+		quota["nvidia.com/gpu"] = *resource.NewMilliQuantity(newGpu, resource.DecimalSI)
+	}
+	return quota
+}
+
+func minInt64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func (q *DRFCohortQueue) cohortOf(pod *corev1.Pod) (*cohortUsage, bool) {
+	name, ok := pod.Labels[LabelCohort]
+	if !ok {
+		return nil, false
+	}
+	cohort, ok := q.cohorts[name]
+	return cohort, ok
+}
+
+// dominantShare computes max_r(used_r / quota_r) over CPU, memory and
+// GPU-milli, treating a zero quota dimension as already saturated (share 1)
+// whenever it would otherwise divide by zero but usage is non-zero.
+func dominantShare(milliCpu, memory, milliGpu int64, quota corev1.ResourceList) float64 {
+	cpuShare := Share(float64(milliCpu), float64(quota.Cpu().MilliValue()))
+	memShare := Share(float64(memory), float64(quota.Memory().Value()))
+	gpuQuota := quota["nvidia.com/gpu"]
+	gpuShare := Share(float64(milliGpu), float64(gpuQuota.MilliValue()))
+
+	share := cpuShare
+	if memShare > share {
+		share = memShare
+	}
+	if gpuShare > share {
+		share = gpuShare
+	}
+	return share
+}
+
+// FairnessReport summarizes, per cohort, the dominant resource share at the
+// point it is computed -- used by the simulator to compare Greed vs. DRF
+// packing outcomes.
+type FairnessReport struct {
+	Cohort                string
+	DominantResourceShare float64
+	Borrowed              int64
+}
+
+func (q *DRFCohortQueue) FairnessReport() []FairnessReport {
+	reports := make([]FairnessReport, 0, len(q.cohorts))
+	for name, cohort := range q.cohorts {
+		reports = append(reports, FairnessReport{
+			Cohort:                name,
+			DominantResourceShare: dominantShare(cohort.usedMilliCpu, cohort.usedMemory, cohort.usedMilliGpu, q.effectiveQuotaOf(name)),
+			Borrowed:              cohort.borrowed,
+		})
+	}
+	return reports
+}
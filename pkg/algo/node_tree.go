@@ -0,0 +1,107 @@
+package algo
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Upstream kube-scheduler's numFeasibleNodesToFind constants
+// (pkg/scheduler/schedule_one.go): below minFeasibleNodesToFind nodes, or at
+// percentage>=100, the scheduler always searches the whole cluster; above
+// that, the percentage never auto-tunes below minFeasibleNodesPercentageToFind.
+const (
+	minFeasibleNodesToFind           = 100
+	minFeasibleNodesPercentageToFind = 5
+	basePercentageOfNodesToScore     = 50
+)
+
+// PercentageOfNodesToScore returns the percentage (1-100) of the cluster's
+// nodes the scheduler should try to find feasible before moving on to
+// scoring. configured is returned unchanged (clamped to 100) when positive;
+// otherwise it's auto-tuned by cluster size the same way upstream derives
+// its default, shrinking as numAllNodes grows, down to a floor of
+// minFeasibleNodesPercentageToFind.
+func PercentageOfNodesToScore(configured int32, numAllNodes int32) int32 {
+	if configured > 0 {
+		if configured > 100 {
+			return 100
+		}
+		return configured
+	}
+	adaptive := int32(basePercentageOfNodesToScore) - numAllNodes/125
+	if adaptive < minFeasibleNodesPercentageToFind {
+		adaptive = minFeasibleNodesPercentageToFind
+	}
+	return adaptive
+}
+
+// NumNodesToFind returns how many feasible nodes the scheduling loop should
+// collect for a numAllNodes-node cluster before it stops and proceeds to
+// scoring, given a PercentageOfNodesToScore value. Small clusters (at or
+// below minFeasibleNodesToFind nodes) or a percentage of 100 or more always
+// search every node.
+func NumNodesToFind(percentageOfNodesToScore, numAllNodes int32) int32 {
+	if numAllNodes <= minFeasibleNodesToFind || percentageOfNodesToScore >= 100 {
+		return numAllNodes
+	}
+	numNodes := numAllNodes * percentageOfNodesToScore / 100
+	if numNodes < minFeasibleNodesToFind {
+		numNodes = minFeasibleNodesToFind
+	}
+	return numNodes
+}
+
+// nodeZoneLabel is the well-known topology label NodeTree groups nodes by.
+const nodeZoneLabel = "topology.kubernetes.io/zone"
+
+// NodeTree groups nodes by zone (the topology.kubernetes.io/zone label,
+// falling back to "" for unlabeled nodes) and round-robins across zones on
+// successive Next calls, modeled on upstream kube-scheduler's internal
+// nodeTree: spreading each scheduling cycle's feasible-node search across
+// the whole cluster instead of always starting from the same node, so that
+// node load spreads evenly over many cycles even when the search stops
+// early (see NumNodesToFind).
+type NodeTree struct {
+	zones     []string
+	nodes     map[string][]string
+	nodeIndex map[string]int
+	zoneIndex int
+	numNodes  int
+}
+
+// NewNodeTree builds a NodeTree over nodes.
+func NewNodeTree(nodes []*corev1.Node) *NodeTree {
+	tree := &NodeTree{
+		nodes:     map[string][]string{},
+		nodeIndex: map[string]int{},
+	}
+	for _, node := range nodes {
+		zone := node.Labels[nodeZoneLabel]
+		if _, ok := tree.nodes[zone]; !ok {
+			tree.zones = append(tree.zones, zone)
+		}
+		tree.nodes[zone] = append(tree.nodes[zone], node.Name)
+		tree.numNodes++
+	}
+	return tree
+}
+
+// Len returns the total number of nodes across every zone.
+func (t *NodeTree) Len() int { return t.numNodes }
+
+// Next returns the next node name in round-robin zone order, cycling each
+// zone's own node list independently. Zones alternate on every call whatever
+// their size, so nodes in smaller zones come up more often, and when zones
+// differ in size a run of Len() calls need not visit every node. Returns ""
+// if the tree has no nodes.
+func (t *NodeTree) Next() string {
+	if len(t.zones) == 0 {
+		return ""
+	}
+	zone := t.zones[t.zoneIndex%len(t.zones)]
+	t.zoneIndex++
+
+	nodeNames := t.nodes[zone]
+	idx := t.nodeIndex[zone] % len(nodeNames)
+	t.nodeIndex[zone] = idx + 1
+	return nodeNames[idx]
+}
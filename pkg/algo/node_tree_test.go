@@ -0,0 +1,103 @@
+package algo
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func nodeInZone(name, zone string) *corev1.Node {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if zone != "" {
+		node.Labels = map[string]string{nodeZoneLabel: zone}
+	}
+	return node
+}
+
+func TestPercentageOfNodesToScore(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured int32
+		numNodes   int32
+		want       int32
+	}{
+		{"configured value is used as-is", 30, 5000, 30},
+		{"configured value clamped to 100", 150, 5000, 100},
+		{"auto-tuned for a small cluster", 0, 100, 50},
+		{"auto-tuned shrinks with cluster size", 0, 2500, 30},
+		{"auto-tuned floors at 5%", 0, 10000, 5},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := PercentageOfNodesToScore(tc.configured, tc.numNodes); got != tc.want {
+				t.Errorf("PercentageOfNodesToScore(%d, %d) = %d, want %d", tc.configured, tc.numNodes, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNumNodesToFind(t *testing.T) {
+	tests := []struct {
+		name       string
+		percentage int32
+		numNodes   int32
+		want       int32
+	}{
+		{"small cluster always searches every node", 5, 50, 50},
+		{"percentage>=100 always searches every node", 100, 5000, 5000},
+		{"percentage of a large cluster above minFeasibleNodesToFind is used as-is", 5, 5000, 250},
+		{"result never drops below minFeasibleNodesToFind", 1, 150, 100},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := NumNodesToFind(tc.percentage, tc.numNodes); got != tc.want {
+				t.Errorf("NumNodesToFind(%d, %d) = %d, want %d", tc.percentage, tc.numNodes, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNodeTreeRoundRobinSingleZone(t *testing.T) {
+	tree := NewNodeTree([]*corev1.Node{
+		nodeInZone("n1", "zone-a"),
+		nodeInZone("n2", "zone-a"),
+		nodeInZone("n3", "zone-a"),
+	})
+	if tree.Len() != 3 {
+		t.Fatalf("tree.Len() = %d, want 3", tree.Len())
+	}
+	want := []string{"n1", "n2", "n3", "n1", "n2"}
+	for i, name := range want {
+		if got := tree.Next(); got != name {
+			t.Errorf("Next() call %d = %s, want %s", i, got, name)
+		}
+	}
+}
+
+func TestNodeTreeRoundRobinAcrossZones(t *testing.T) {
+	tree := NewNodeTree([]*corev1.Node{
+		nodeInZone("a1", "zone-a"),
+		nodeInZone("a2", "zone-a"),
+		nodeInZone("b1", "zone-b"),
+	})
+
+	// Zones alternate every call; zone-a's own two nodes cycle independently
+	// of zone-b's single node, so zone-b (the smaller zone) repeats sooner.
+	want := []string{"a1", "b1", "a2", "b1", "a1", "b1"}
+	for i, name := range want {
+		if got := tree.Next(); got != name {
+			t.Errorf("Next() call %d = %s, want %s", i, got, name)
+		}
+	}
+}
+
+func TestNodeTreeEmpty(t *testing.T) {
+	tree := NewNodeTree(nil)
+	if tree.Len() != 0 {
+		t.Errorf("tree.Len() = %d, want 0", tree.Len())
+	}
+	if got := tree.Next(); got != "" {
+		t.Errorf("Next() on an empty tree = %q, want \"\"", got)
+	}
+}
@@ -0,0 +1,186 @@
+package algo
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	resourcehelper "k8s.io/kubectl/pkg/util/resource"
+)
+
+// HostPriority is one node's score, either raw (fresh out of a
+// PriorityMapFunc) or final (after a ReduceFunc has rescaled it), mirroring
+// the shape upstream's legacy (pre-framework) scheduler priority functions
+// used to pass scores between their map and reduce phases.
+type HostPriority struct {
+	Host  string
+	Score int64
+}
+
+// HostPriorityList is a slice of per-node scores produced by a
+// PriorityMapFunc over every feasible node and rescaled in place by a
+// ReduceFunc.
+type HostPriorityList []HostPriority
+
+// PriorityMapFunc computes pod's raw, not-yet-normalized score on node --
+// the map half of a two-phase priority function. podsOnNode lists the pods
+// already bound to node, for map funcs that need to know current usage;
+// funcs that don't care about it (e.g. one based purely on node.Spec.Taints)
+// are free to ignore the argument.
+type PriorityMapFunc func(pod *corev1.Pod, node *corev1.Node, podsOnNode []*corev1.Pod) (int64, error)
+
+// ReduceFunc rescales every entry of list in place from raw scores (whatever
+// range a PriorityMapFunc happens to produce) into a common final range.
+type ReduceFunc func(list HostPriorityList)
+
+// NormalizeReduce builds a ReduceFunc that linearly rescales list's raw
+// scores into [0, maxPriority], the two-phase map-then-reduce model upstream
+// kube-scheduler's legacy priority functions used (see
+// pkg/scheduler/algorithm/priorities): the map phase computes a cheap raw
+// per-node score in whatever units are convenient, and a single reduce pass
+// finds the maximum and rescales every node against it, so priority
+// functions with very different raw score ranges (bytes free, toleration
+// seconds, intolerable-taint counts, ...) can still be weighted and combined
+// on a common [0, maxPriority] scale.
+//
+// When reverse is true, the rescaled order is inverted (a lower raw score
+// yields a higher final score), for priority functions like
+// TaintTolerationMap where fewer is better. If every node's raw score is 0
+// (maxCount == 0), every node gets maxPriority when reverse is set -- no
+// node is worse than any other -- and 0 otherwise.
+func NormalizeReduce(maxPriority int, reverse bool) ReduceFunc {
+	return func(list HostPriorityList) {
+		var maxCount int64
+		for _, hp := range list {
+			if hp.Score > maxCount {
+				maxCount = hp.Score
+			}
+		}
+
+		for i, hp := range list {
+			var score int64
+			if maxCount == 0 {
+				if reverse {
+					score = int64(maxPriority)
+				}
+			} else {
+				score = hp.Score * int64(maxPriority) / maxCount
+				if reverse {
+					score = int64(maxPriority) - score
+				}
+			}
+			list[i].Score = score
+		}
+	}
+}
+
+// RunPriorityFunction runs mapFn over every node in nodes, then reduceFn over
+// the resulting list, the two-phase pipeline a single priority function
+// follows end to end.
+func RunPriorityFunction(pod *corev1.Pod, nodes []*corev1.Node, podsByNode map[string][]*corev1.Pod, mapFn PriorityMapFunc, reduceFn ReduceFunc) (HostPriorityList, error) {
+	list := make(HostPriorityList, len(nodes))
+	for i, node := range nodes {
+		score, err := mapFn(pod, node, podsByNode[node.Name])
+		if err != nil {
+			return nil, err
+		}
+		list[i] = HostPriority{Host: node.Name, Score: score}
+	}
+	reduceFn(list)
+	return list, nil
+}
+
+// CombineScores sums each host's score across lists, weighted by the
+// matching entry in weights, to merge several already-normalized
+// HostPriorityLists (see NormalizeReduce) into one -- e.g. combining
+// BalancedResourceAllocationMap and TaintTolerationMap's normalized outputs
+// with different weights, the same way upstream's
+// genericScheduler.PrioritizeNodes sums weighted priority results. Every
+// list must cover the same hosts in the same order.
+func CombineScores(lists []HostPriorityList, weights []int64) HostPriorityList {
+	if len(lists) == 0 {
+		return nil
+	}
+	combined := make(HostPriorityList, len(lists[0]))
+	for i, hp := range lists[0] {
+		combined[i].Host = hp.Host
+	}
+	for li, list := range lists {
+		for i, hp := range list {
+			combined[i].Score += hp.Score * weights[li]
+		}
+	}
+	return combined
+}
+
+// clampFraction returns used/capacity clamped to [0, 1], 0 if capacity isn't
+// positive.
+func clampFraction(used, capacity int64) float64 {
+	if capacity <= 0 {
+		return 0
+	}
+	f := float64(used) / float64(capacity)
+	if f > 1 {
+		return 1
+	}
+	if f < 0 {
+		return 0
+	}
+	return f
+}
+
+// BalancedResourceAllocationMap is the map half of a balanced-resource
+// priority function: a raw "imbalance" score for node -- how far apart its
+// post-scheduling CPU and memory usage fractions would be -- scaled to an
+// integer (x1000) so NormalizeReduce's int64 arithmetic keeps useful
+// precision. Pair it with NormalizeReduce(maxPriority, true): the more
+// balanced a node's CPU/memory usage would be, the smaller this raw score
+// and the higher its final, reduced priority. Modeled on the variance
+// approach of
+// pkg/scheduler/framework/plugins/noderesources/balanced_allocation.go,
+// restricted to CPU/memory since this package doesn't have a node's extended
+// resources to hand.
+func BalancedResourceAllocationMap(pod *corev1.Pod, node *corev1.Node, podsOnNode []*corev1.Pod) (int64, error) {
+	allocatableMilliCpu := node.Status.Allocatable.Cpu().MilliValue()
+	allocatableMemory := node.Status.Allocatable.Memory().Value()
+
+	var usedMilliCpu, usedMemory int64
+	for _, p := range podsOnNode {
+		req, _ := resourcehelper.PodRequestsAndLimits(p)
+		usedMilliCpu += req.Cpu().MilliValue()
+		usedMemory += req.Memory().Value()
+	}
+	podReq, _ := resourcehelper.PodRequestsAndLimits(pod)
+
+	cpuFraction := clampFraction(usedMilliCpu+podReq.Cpu().MilliValue(), allocatableMilliCpu)
+	memFraction := clampFraction(usedMemory+podReq.Memory().Value(), allocatableMemory)
+
+	diff := cpuFraction - memFraction
+	if diff < 0 {
+		diff = -diff
+	}
+	return int64(diff * 1000), nil
+}
+
+// TaintTolerationMap is the map half of the taint-toleration priority
+// function: a raw score counting node's PreferNoSchedule taints pod doesn't
+// tolerate, modeled on
+// pkg/scheduler/algorithm/priorities/taint_toleration.go's
+// countIntolerableTaints. Pair it with NormalizeReduce(maxPriority, true) so
+// fewer intolerable taints yields a higher final score.
+func TaintTolerationMap(pod *corev1.Pod, node *corev1.Node, _ []*corev1.Pod) (int64, error) {
+	var intolerable int64
+	for _, taint := range node.Spec.Taints {
+		if taint.Effect != corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		tolerated := false
+		for i := range pod.Spec.Tolerations {
+			if tolerationToleratesTaint(&pod.Spec.Tolerations[i], &taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			intolerable++
+		}
+	}
+	return intolerable, nil
+}
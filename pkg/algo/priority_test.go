@@ -0,0 +1,168 @@
+package algo
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNormalizeReduce(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxPriority int
+		reverse     bool
+		list        HostPriorityList
+		want        []int64
+	}{
+		{
+			name:        "forward scales against the max",
+			maxPriority: 10,
+			reverse:     false,
+			list:        HostPriorityList{{Host: "a", Score: 0}, {Host: "b", Score: 50}, {Host: "c", Score: 100}},
+			want:        []int64{0, 5, 10},
+		},
+		{
+			name:        "reverse inverts the scaled score",
+			maxPriority: 10,
+			reverse:     true,
+			list:        HostPriorityList{{Host: "a", Score: 0}, {Host: "b", Score: 50}, {Host: "c", Score: 100}},
+			want:        []int64{10, 5, 0},
+		},
+		{
+			name:        "all-zero raw scores forward to 0",
+			maxPriority: 10,
+			reverse:     false,
+			list:        HostPriorityList{{Host: "a", Score: 0}, {Host: "b", Score: 0}},
+			want:        []int64{0, 0},
+		},
+		{
+			name:        "all-zero raw scores reverse to maxPriority",
+			maxPriority: 10,
+			reverse:     true,
+			list:        HostPriorityList{{Host: "a", Score: 0}, {Host: "b", Score: 0}},
+			want:        []int64{10, 10},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			reduce := NormalizeReduce(tc.maxPriority, tc.reverse)
+			reduce(tc.list)
+			for i, hp := range tc.list {
+				if hp.Score != tc.want[i] {
+					t.Errorf("list[%d].Score = %d, want %d", i, hp.Score, tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCombineScores(t *testing.T) {
+	a := HostPriorityList{{Host: "n1", Score: 10}, {Host: "n2", Score: 0}}
+	b := HostPriorityList{{Host: "n1", Score: 0}, {Host: "n2", Score: 10}}
+
+	combined := CombineScores([]HostPriorityList{a, b}, []int64{2, 1})
+	want := []int64{20, 10}
+	for i, hp := range combined {
+		if hp.Score != want[i] {
+			t.Errorf("combined[%d].Score = %d, want %d", i, hp.Score, want[i])
+		}
+	}
+}
+
+func nodeWithAllocatable(name string, cpuMilli, memory int64) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    *resource.NewMilliQuantity(cpuMilli, resource.DecimalSI),
+				corev1.ResourceMemory: *resource.NewQuantity(memory, resource.BinarySI),
+			},
+		},
+	}
+}
+
+func podRequesting(cpuMilli, memory int64) *corev1.Pod {
+	return &corev1.Pod{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    *resource.NewMilliQuantity(cpuMilli, resource.DecimalSI),
+						corev1.ResourceMemory: *resource.NewQuantity(memory, resource.BinarySI),
+					},
+				},
+			}},
+		},
+	}
+}
+
+func TestBalancedResourceAllocationMap(t *testing.T) {
+	node := nodeWithAllocatable("n", 1000, 1000)
+
+	balanced := podRequesting(500, 500)
+	if score, err := BalancedResourceAllocationMap(balanced, node, nil); err != nil || score != 0 {
+		t.Errorf("BalancedResourceAllocationMap() = (%d, %v), want (0, nil) for equal cpu/mem fractions", score, err)
+	}
+
+	skewed := podRequesting(1000, 0)
+	score, err := BalancedResourceAllocationMap(skewed, node, nil)
+	if err != nil {
+		t.Fatalf("BalancedResourceAllocationMap() error = %v", err)
+	}
+	if score != 1000 {
+		t.Errorf("BalancedResourceAllocationMap() = %d, want 1000 for a fully cpu-only request", score)
+	}
+}
+
+func TestTaintTolerationMap(t *testing.T) {
+	node := &corev1.Node{
+		Spec: corev1.NodeSpec{
+			Taints: []corev1.Taint{
+				{Key: "a", Value: "v", Effect: corev1.TaintEffectPreferNoSchedule},
+				{Key: "b", Value: "v", Effect: corev1.TaintEffectPreferNoSchedule},
+				{Key: "c", Value: "v", Effect: corev1.TaintEffectNoSchedule},
+			},
+		},
+	}
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			Tolerations: []corev1.Toleration{
+				{Key: "a", Value: "v", Effect: corev1.TaintEffectPreferNoSchedule},
+			},
+		},
+	}
+
+	score, err := TaintTolerationMap(pod, node, nil)
+	if err != nil {
+		t.Fatalf("TaintTolerationMap() error = %v", err)
+	}
+	// "a" is tolerated, "b" (PreferNoSchedule) isn't, "c" is ignored because
+	// it isn't PreferNoSchedule.
+	if score != 1 {
+		t.Errorf("TaintTolerationMap() = %d, want 1", score)
+	}
+}
+
+func TestRunPriorityFunctionTaintToleration(t *testing.T) {
+	clean := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "clean"}}
+	tainted := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "tainted"},
+		Spec: corev1.NodeSpec{
+			Taints: []corev1.Taint{{Key: "a", Value: "v", Effect: corev1.TaintEffectPreferNoSchedule}},
+		},
+	}
+	pod := &corev1.Pod{}
+
+	list, err := RunPriorityFunction(pod, []*corev1.Node{clean, tainted}, nil, TaintTolerationMap, NormalizeReduce(10, true))
+	if err != nil {
+		t.Fatalf("RunPriorityFunction() error = %v", err)
+	}
+	if list[0].Host != "clean" || list[0].Score != 10 {
+		t.Errorf("clean node score = %+v, want Score 10", list[0])
+	}
+	if list[1].Host != "tainted" || list[1].Score != 0 {
+		t.Errorf("tainted node score = %+v, want Score 0", list[1])
+	}
+}
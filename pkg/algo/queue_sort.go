@@ -0,0 +1,132 @@
+package algo
+
+import (
+	"container/heap"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// QueueSortPlugin orders two pods in a SchedulingQueue, modeled on the
+// scheduling-framework "queue-sort" extension point: exactly one plugin is
+// active in a queue at a time, and Less must be a strict weak ordering.
+type QueueSortPlugin interface {
+	Name() string
+	Less(a, b *PodInfo) bool
+}
+
+// queueSortPluginFactories is the process-wide registry of known
+// QueueSortPlugins, the same factory-registry pattern
+// pkg/simulator/plugin/resource_handler.go uses for ResourceHandler.
+var queueSortPluginFactories = map[string]func() QueueSortPlugin{}
+
+// RegisterQueueSortPlugin adds factory under name. Re-registering an existing
+// name overwrites it, matching how the upstream scheduler treats repeated
+// plugin registration.
+func RegisterQueueSortPlugin(name string, factory func() QueueSortPlugin) {
+	queueSortPluginFactories[name] = factory
+}
+
+// NewQueueSortPlugin builds the plugin registered under name, or an error if
+// name isn't a known queue-sort plugin.
+func NewQueueSortPlugin(name string) (QueueSortPlugin, error) {
+	factory, ok := queueSortPluginFactories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown queue-sort plugin %q", name)
+	}
+	return factory(), nil
+}
+
+// priorityPlugin orders pods by ByPriority alone.
+type priorityPlugin struct{}
+
+func (priorityPlugin) Name() string           { return "Priority" }
+func (priorityPlugin) Less(a, b *PodInfo) bool { return ByPriority(a, b) }
+
+// tolerationPlugin orders pods by ByToleration alone.
+type tolerationPlugin struct{}
+
+func (tolerationPlugin) Name() string           { return "Toleration" }
+func (tolerationPlugin) Less(a, b *PodInfo) bool { return ByToleration(a, b) }
+
+// fifoPlugin orders pods by arrival order alone, for benchmarking
+// starvation-avoidance against priority-based policies.
+type fifoPlugin struct{}
+
+func (fifoPlugin) Name() string           { return "FIFO" }
+func (fifoPlugin) Less(a, b *PodInfo) bool { return ByCreationTimestamp(a, b) }
+
+// priorityThenTolerationPlugin is DefaultLess (priority, then toleration,
+// then creation timestamp) exposed as a named, registrable plugin.
+type priorityThenTolerationPlugin struct{}
+
+func (priorityThenTolerationPlugin) Name() string { return "PriorityThenToleration" }
+func (priorityThenTolerationPlugin) Less(a, b *PodInfo) bool {
+	return DefaultLess(a, b)
+}
+
+func init() {
+	RegisterQueueSortPlugin("Priority", func() QueueSortPlugin { return priorityPlugin{} })
+	RegisterQueueSortPlugin("Toleration", func() QueueSortPlugin { return tolerationPlugin{} })
+	RegisterQueueSortPlugin("FIFO", func() QueueSortPlugin { return fifoPlugin{} })
+	RegisterQueueSortPlugin("PriorityThenToleration", func() QueueSortPlugin { return priorityThenTolerationPlugin{} })
+}
+
+// SchedulingQueue is a heap of pending pods ordered by a QueueSortPlugin, so
+// callers can swap ordering policy (important-soon priority,
+// starvation-avoidance FIFO, toleration-aware eviction ordering, ...) without
+// changing how pods are added to or popped from the queue.
+type SchedulingQueue struct {
+	items  []*PodInfo
+	plugin QueueSortPlugin
+}
+
+var _ heap.Interface = &SchedulingQueue{}
+
+// NewSchedulingQueue builds a queue over pods ordered by plugin.
+func NewSchedulingQueue(plugin QueueSortPlugin, pods []*corev1.Pod) *SchedulingQueue {
+	items := make([]*PodInfo, len(pods))
+	for i, pod := range pods {
+		items[i] = NewPodInfo(pod)
+	}
+	q := &SchedulingQueue{items: items, plugin: plugin}
+	heap.Init(q)
+	return q
+}
+
+// NewSchedulingQueueByName builds a queue over pods using the plugin
+// registered under name (see RegisterQueueSortPlugin).
+func NewSchedulingQueueByName(name string, pods []*corev1.Pod) (*SchedulingQueue, error) {
+	plugin, err := NewQueueSortPlugin(name)
+	if err != nil {
+		return nil, err
+	}
+	return NewSchedulingQueue(plugin, pods), nil
+}
+
+func (q *SchedulingQueue) Len() int           { return len(q.items) }
+func (q *SchedulingQueue) Less(i, j int) bool { return q.plugin.Less(q.items[i], q.items[j]) }
+func (q *SchedulingQueue) Swap(i, j int)      { q.items[i], q.items[j] = q.items[j], q.items[i] }
+func (q *SchedulingQueue) Push(x interface{}) { q.items = append(q.items, x.(*PodInfo)) }
+func (q *SchedulingQueue) Pop() interface{} {
+	old := q.items
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	q.items = old[:n-1]
+	return item
+}
+
+// Add pushes pod onto the queue, maintaining heap order.
+func (q *SchedulingQueue) Add(pod *corev1.Pod) {
+	heap.Push(q, NewPodInfo(pod))
+}
+
+// PopPod removes and returns the front pod per q.plugin's ordering, or nil if
+// the queue is empty.
+func (q *SchedulingQueue) PopPod() *corev1.Pod {
+	if q.Len() == 0 {
+		return nil
+	}
+	return heap.Pop(q).(*PodInfo).Pod
+}
@@ -0,0 +1,78 @@
+package algo
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestNewQueueSortPluginUnknown(t *testing.T) {
+	if _, err := NewQueueSortPlugin("DoesNotExist"); err == nil {
+		t.Errorf("expected an error for an unregistered plugin name")
+	}
+}
+
+func TestBuiltinQueueSortPlugins(t *testing.T) {
+	for _, name := range []string{"Priority", "Toleration", "FIFO", "PriorityThenToleration"} {
+		plugin, err := NewQueueSortPlugin(name)
+		if err != nil {
+			t.Fatalf("NewQueueSortPlugin(%q) returned error: %v", name, err)
+		}
+		if plugin.Name() != name {
+			t.Errorf("plugin.Name() = %q, want %q", plugin.Name(), name)
+		}
+	}
+}
+
+func TestSchedulingQueuePriorityOrder(t *testing.T) {
+	low := podWithPriority("low", 1)
+	high := podWithPriority("high", 10)
+	mid := podWithPriority("mid", 5)
+
+	plugin, err := NewQueueSortPlugin("Priority")
+	if err != nil {
+		t.Fatalf("NewQueueSortPlugin: %v", err)
+	}
+	q := NewSchedulingQueue(plugin, []*corev1.Pod{low, high, mid})
+
+	want := []string{"high", "mid", "low"}
+	for _, name := range want {
+		pod := q.PopPod()
+		if pod == nil || pod.Name != name {
+			t.Fatalf("PopPod() = %v, want %s", pod, name)
+		}
+	}
+	if q.PopPod() != nil {
+		t.Errorf("expected PopPod() to return nil once the queue is drained")
+	}
+}
+
+func TestSchedulingQueueByName(t *testing.T) {
+	shortLived := podWithTolerationSeconds("short", seconds(5))
+	longLived := podWithTolerationSeconds("long", seconds(60))
+
+	q, err := NewSchedulingQueueByName("Toleration", []*corev1.Pod{longLived, shortLived})
+	if err != nil {
+		t.Fatalf("NewSchedulingQueueByName: %v", err)
+	}
+	if pod := q.PopPod(); pod.Name != "short" {
+		t.Errorf("PopPod() = %s, want short", pod.Name)
+	}
+	if pod := q.PopPod(); pod.Name != "long" {
+		t.Errorf("PopPod() = %s, want long", pod.Name)
+	}
+}
+
+func TestSchedulingQueueAdd(t *testing.T) {
+	plugin, err := NewQueueSortPlugin("Priority")
+	if err != nil {
+		t.Fatalf("NewQueueSortPlugin: %v", err)
+	}
+	q := NewSchedulingQueue(plugin, nil)
+	q.Add(podWithPriority("low", 1))
+	q.Add(podWithPriority("high", 10))
+
+	if pod := q.PopPod(); pod.Name != "high" {
+		t.Errorf("PopPod() = %s, want high", pod.Name)
+	}
+}
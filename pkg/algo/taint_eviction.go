@@ -0,0 +1,139 @@
+package algo
+
+import (
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// infiniteTolerationDuration is the sentinel MinTolerationTime returns for a
+// pod that tolerates every given NoExecute taint forever (or faces no
+// NoExecute taint at all), mirroring how -1/nil TolerationSeconds are treated
+// as "forever" elsewhere in this package.
+const infiniteTolerationDuration = time.Duration(1<<63 - 1)
+
+// tolerationToleratesTaint reports whether t tolerates taint, mirroring
+// corev1.Toleration.ToleratesTaint from upstream client-go (reimplemented
+// here since that method isn't available on the vendored type in this tree).
+func tolerationToleratesTaint(t *corev1.Toleration, taint *corev1.Taint) bool {
+	if t.Effect != "" && t.Effect != taint.Effect {
+		return false
+	}
+	if t.Key != "" && t.Key != taint.Key {
+		return false
+	}
+	switch t.Operator {
+	case corev1.TolerationOpExists:
+		return true
+	case "", corev1.TolerationOpEqual:
+		return t.Value == taint.Value
+	default:
+		return false
+	}
+}
+
+// MinTolerationTime returns how long pod may remain on a node tainted with
+// taints before a NoExecute-triggered eviction. For every taint in taints
+// with Effect NoExecute: a toleration on pod matching it with
+// TolerationSeconds left nil tolerates that taint forever; a matching
+// toleration with TolerationSeconds <= 0 means immediate eviction; no
+// toleration on pod matching that taint at all also means immediate
+// eviction. The pod's overall eviction time is the minimum across every
+// NoExecute taint, or infiniteTolerationDuration if there are none (or every
+// one of them is tolerated forever).
+func MinTolerationTime(pod *corev1.Pod, taints []corev1.Taint) time.Duration {
+	min := infiniteTolerationDuration
+	for _, taint := range taints {
+		if taint.Effect != corev1.TaintEffectNoExecute {
+			continue
+		}
+
+		matched := false
+		taintDuration := infiniteTolerationDuration
+		for i := range pod.Spec.Tolerations {
+			t := &pod.Spec.Tolerations[i]
+			if !tolerationToleratesTaint(t, &taint) {
+				continue
+			}
+			matched = true
+			if t.TolerationSeconds == nil {
+				continue
+			}
+			s := *t.TolerationSeconds
+			if s < 0 {
+				s = 0
+			}
+			if d := time.Duration(s) * time.Second; d < taintDuration {
+				taintDuration = d
+			}
+		}
+		if !matched {
+			return 0
+		}
+		if taintDuration < min {
+			min = taintDuration
+		}
+	}
+	return min
+}
+
+// TaintEvictionQueue sorts pods by MinTolerationTime against a fixed set of
+// taints -- typically those just applied to a node mid-simulation -- so the
+// simulator can reproduce the order the real taint-eviction controller would
+// evict them in: pods that tolerate the taint only briefly, or not at all,
+// are evicted first.
+type TaintEvictionQueue struct {
+	pods   []*corev1.Pod
+	taints []corev1.Taint
+}
+
+var _ sort.Interface = &TaintEvictionQueue{}
+
+// NewTaintEvictionQueue builds a queue over pods ordered by how soon each
+// would be evicted from a node tainted with taints.
+func NewTaintEvictionQueue(pods []*corev1.Pod, taints []corev1.Taint) *TaintEvictionQueue {
+	return &TaintEvictionQueue{pods: pods, taints: taints}
+}
+
+func (q *TaintEvictionQueue) Len() int      { return len(q.pods) }
+func (q *TaintEvictionQueue) Swap(i, j int) { q.pods[i], q.pods[j] = q.pods[j], q.pods[i] }
+func (q *TaintEvictionQueue) Less(i, j int) bool {
+	return MinTolerationTime(q.pods[i], q.taints) < MinTolerationTime(q.pods[j], q.taints)
+}
+
+// taintEvictionPlugin adapts MinTolerationTime to the QueueSortPlugin
+// interface against a fixed set of taints, so taint-eviction ordering can
+// drive a SchedulingQueue the same way Priority/Toleration/FIFO do instead of
+// only being reachable through the standalone TaintEvictionQueue.
+//
+// It isn't registered in queueSortPluginFactories: every other factory there
+// takes no arguments because it orders purely on a pod's own static
+// attributes, but taint-eviction order depends on which taints were just
+// applied to a node, known only to the caller simulating that eviction. Build
+// one with NewTaintEvictionPlugin at that call site instead of by name.
+//
+// As of this trimmed tree, there is no such call site: nothing outside this
+// package's own tests constructs a taintEvictionPlugin or a
+// TaintEvictionQueue, since the taint-eviction simulation that would know
+// which taints were just applied to a node (and would call
+// NewTaintEvictionPlugin/NewTaintEvictionQueue with them) lives, if anywhere,
+// inside the simulator implementation behind the Interface declared in
+// pkg/simulator/core.go -- not in any file this package can see or edit.
+// This type is ready to be wired in once that caller exists.
+type taintEvictionPlugin struct {
+	taints []corev1.Taint
+}
+
+// NewTaintEvictionPlugin builds a QueueSortPlugin that orders pods by
+// MinTolerationTime against taints, for use with NewSchedulingQueue when
+// simulating a taint-based eviction.
+func NewTaintEvictionPlugin(taints []corev1.Taint) QueueSortPlugin {
+	return taintEvictionPlugin{taints: taints}
+}
+
+func (p taintEvictionPlugin) Name() string { return "TaintEviction" }
+
+func (p taintEvictionPlugin) Less(a, b *PodInfo) bool {
+	return MinTolerationTime(a.Pod, p.taints) < MinTolerationTime(b.Pod, p.taints)
+}
@@ -0,0 +1,135 @@
+package algo
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func taint(key string, effect corev1.TaintEffect) corev1.Taint {
+	return corev1.Taint{Key: key, Value: "v", Effect: effect}
+}
+
+func podWithToleration(name string, toleration corev1.Toleration) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       corev1.PodSpec{Tolerations: []corev1.Toleration{toleration}},
+	}
+}
+
+func TestMinTolerationTime(t *testing.T) {
+	noExecute := taint("dedicated", corev1.TaintEffectNoExecute)
+
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		want time.Duration
+	}{
+		{
+			name: "no matching toleration",
+			pod:  &corev1.Pod{},
+			want: 0,
+		},
+		{
+			name: "matches by key/value/effect, nil TolerationSeconds tolerates forever",
+			pod: podWithToleration("p", corev1.Toleration{
+				Key: "dedicated", Value: "v", Effect: corev1.TaintEffectNoExecute,
+			}),
+			want: infiniteTolerationDuration,
+		},
+		{
+			name: "Exists operator matches regardless of value",
+			pod: podWithToleration("p", corev1.Toleration{
+				Key: "dedicated", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute,
+			}),
+			want: infiniteTolerationDuration,
+		},
+		{
+			name: "finite TolerationSeconds",
+			pod: podWithToleration("p", corev1.Toleration{
+				Key: "dedicated", Value: "v", Effect: corev1.TaintEffectNoExecute, TolerationSeconds: seconds(30),
+			}),
+			want: 30 * time.Second,
+		},
+		{
+			name: "non-positive TolerationSeconds means immediate eviction",
+			pod: podWithToleration("p", corev1.Toleration{
+				Key: "dedicated", Value: "v", Effect: corev1.TaintEffectNoExecute, TolerationSeconds: seconds(-5),
+			}),
+			want: 0,
+		},
+		{
+			name: "toleration for a different key doesn't match",
+			pod: podWithToleration("p", corev1.Toleration{
+				Key: "other", Value: "v", Effect: corev1.TaintEffectNoExecute,
+			}),
+			want: 0,
+		},
+		{
+			name: "toleration without an effect matches any effect",
+			pod: podWithToleration("p", corev1.Toleration{
+				Key: "dedicated", Value: "v", TolerationSeconds: seconds(10),
+			}),
+			want: 10 * time.Second,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := MinTolerationTime(tc.pod, []corev1.Taint{noExecute}); got != tc.want {
+				t.Errorf("MinTolerationTime() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMinTolerationTimeIgnoresNonNoExecuteTaints(t *testing.T) {
+	pod := &corev1.Pod{}
+	taints := []corev1.Taint{taint("dedicated", corev1.TaintEffectNoSchedule)}
+	if got := MinTolerationTime(pod, taints); got != infiniteTolerationDuration {
+		t.Errorf("MinTolerationTime() = %v, want infiniteTolerationDuration for a NoSchedule-only taint list", got)
+	}
+}
+
+func TestMinTolerationTimeMinimumAcrossTaints(t *testing.T) {
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			Tolerations: []corev1.Toleration{
+				{Key: "a", Value: "v", Effect: corev1.TaintEffectNoExecute, TolerationSeconds: seconds(60)},
+				{Key: "b", Value: "v", Effect: corev1.TaintEffectNoExecute, TolerationSeconds: seconds(5)},
+			},
+		},
+	}
+	taints := []corev1.Taint{
+		{Key: "a", Value: "v", Effect: corev1.TaintEffectNoExecute},
+		{Key: "b", Value: "v", Effect: corev1.TaintEffectNoExecute},
+	}
+	if got := MinTolerationTime(pod, taints); got != 5*time.Second {
+		t.Errorf("MinTolerationTime() = %v, want 5s (the shorter of the two matching taints)", got)
+	}
+}
+
+func TestTaintEvictionQueueSort(t *testing.T) {
+	noExecute := taint("dedicated", corev1.TaintEffectNoExecute)
+
+	brief := podWithToleration("brief", corev1.Toleration{
+		Key: "dedicated", Value: "v", Effect: corev1.TaintEffectNoExecute, TolerationSeconds: seconds(5),
+	})
+	longLived := podWithToleration("long", corev1.Toleration{
+		Key: "dedicated", Value: "v", Effect: corev1.TaintEffectNoExecute, TolerationSeconds: seconds(300),
+	})
+	untolerated := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "untolerated"}}
+
+	pods := []*corev1.Pod{longLived, brief, untolerated}
+	q := NewTaintEvictionQueue(pods, []corev1.Taint{noExecute})
+	sort.Sort(q)
+
+	want := []string{"untolerated", "brief", "long"}
+	for i, name := range want {
+		if pods[i].Name != name {
+			t.Errorf("pods[%d].Name = %s, want %s", i, pods[i].Name, name)
+		}
+	}
+}
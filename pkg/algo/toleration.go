@@ -1,22 +1,198 @@
 package algo
 
 import (
+	"sort"
+
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1helpers "k8s.io/component-helpers/scheduling/corev1"
 )
 
+// PodInfo wraps a pod together with the scheduling attributes queue
+// comparators need repeatedly -- priority, min toleration seconds, affinity
+// term counts, creation timestamp -- computed once by NewPodInfo instead of
+// being re-derived from pod.Spec on every heap sift.
+type PodInfo struct {
+	Pod *corev1.Pod
+
+	Priority               int32
+	MinTolerationSeconds   int64
+	RequiredAffinityTerms  int
+	PreferredAffinityTerms int
+	CreationTimestamp      metav1.Time
+}
+
+// NewPodInfo builds a PodInfo with its cached attributes populated from pod.
+func NewPodInfo(pod *corev1.Pod) *PodInfo {
+	pi := &PodInfo{}
+	pi.Update(pod)
+	return pi
+}
+
+// Update refreshes pi's cached attributes from pod, for callers that mutate a
+// pod in place (e.g. a toleration or priority patch) and need a queue's
+// comparisons to reflect the new state without rebuilding the PodInfo.
+func (pi *PodInfo) Update(pod *corev1.Pod) {
+	pi.Pod = pod
+	pi.Priority = corev1helpers.PodPriority(pod)
+	pi.MinTolerationSeconds = minTolerationSeconds(pod)
+	pi.RequiredAffinityTerms, pi.PreferredAffinityTerms = affinityTermCounts(pod)
+	pi.CreationTimestamp = pod.CreationTimestamp
+}
+
+// LessFunc reports whether PodInfo a should sort before b in a
+// TolerationQueue. It compares cached attributes rather than re-walking
+// pod.Spec, so comparators can be composed and swapped without repeated
+// pod-spec traversals during a heap sift.
+type LessFunc func(a, b *PodInfo) bool
+
+// ByPriority orders pods by their cached Priority (corev1.PodPriority --
+// Spec.Priority, resolved via PriorityClassName, defaulting to 0), higher
+// priority first -- the same comparison upstream's PrioritySort queue-sort
+// plugin makes.
+func ByPriority(a, b *PodInfo) bool {
+	return a.Priority > b.Priority
+}
+
+// infiniteTolerationSeconds is the sentinel upstream uses for "tolerates a
+// NoExecute taint forever": either no toleration on the pod sets a duration
+// at all, or one explicitly sets TolerationSeconds to -1.
+const infiniteTolerationSeconds = int64(-1)
+
+// minTolerationSeconds returns the smallest TolerationSeconds across pod's
+// Tolerations, mirroring upstream's getMinTolerationTime. A toleration with
+// TolerationSeconds left nil, or set to -1, tolerates forever and is treated
+// as infiniteTolerationSeconds; a pod with no tolerations at all is infinite
+// too, since nothing bounds how long it can sit on a tainted node.
+func minTolerationSeconds(pod *corev1.Pod) int64 {
+	min := infiniteTolerationSeconds
+	for _, t := range pod.Spec.Tolerations {
+		if t.TolerationSeconds == nil {
+			continue
+		}
+		s := *t.TolerationSeconds
+		if s == infiniteTolerationSeconds {
+			continue
+		}
+		if min == infiniteTolerationSeconds || s < min {
+			min = s
+		}
+	}
+	return min
+}
+
+// affinityTermCounts returns the number of required and preferred affinity
+// terms (node affinity plus pod affinity/anti-affinity) on pod. Queue
+// comparators that want to favor cheap-to-evaluate pods can use these cached
+// counts as a proxy for affinity-evaluation cost without re-walking
+// pod.Spec.Affinity themselves.
+func affinityTermCounts(pod *corev1.Pod) (required, preferred int) {
+	affinity := pod.Spec.Affinity
+	if affinity == nil {
+		return 0, 0
+	}
+	if na := affinity.NodeAffinity; na != nil {
+		if req := na.RequiredDuringSchedulingIgnoredDuringExecution; req != nil {
+			required += len(req.NodeSelectorTerms)
+		}
+		preferred += len(na.PreferredDuringSchedulingIgnoredDuringExecution)
+	}
+	if pa := affinity.PodAffinity; pa != nil {
+		required += len(pa.RequiredDuringSchedulingIgnoredDuringExecution)
+		preferred += len(pa.PreferredDuringSchedulingIgnoredDuringExecution)
+	}
+	if paa := affinity.PodAntiAffinity; paa != nil {
+		required += len(paa.RequiredDuringSchedulingIgnoredDuringExecution)
+		preferred += len(paa.PreferredDuringSchedulingIgnoredDuringExecution)
+	}
+	return required, preferred
+}
+
+// ByToleration orders pods by cached MinTolerationSeconds, ascending: a pod
+// that can only tolerate a NoExecute taint briefly sorts first, and pods
+// tolerating forever (no TolerationSeconds set anywhere, or an explicit -1)
+// sort last.
+func ByToleration(a, b *PodInfo) bool {
+	ta, tb := a.MinTolerationSeconds, b.MinTolerationSeconds
+	if ta == tb {
+		return false
+	}
+	if ta == infiniteTolerationSeconds {
+		return false
+	}
+	if tb == infiniteTolerationSeconds {
+		return true
+	}
+	return ta < tb
+}
+
+// ByCreationTimestamp breaks remaining ties by arrival order, oldest first --
+// PrioritySort's own final tiebreaker.
+func ByCreationTimestamp(a, b *PodInfo) bool {
+	return a.CreationTimestamp.Before(&b.CreationTimestamp)
+}
+
+// Composite chains LessFuncs from highest to lowest precedence: the first
+// func on which a and b disagree decides the order; if every func ties, a and
+// b are equal.
+func Composite(fns ...LessFunc) LessFunc {
+	return func(a, b *PodInfo) bool {
+		for _, f := range fns {
+			if f(a, b) {
+				return true
+			}
+			if f(b, a) {
+				return false
+			}
+		}
+		return false
+	}
+}
+
+// DefaultLess mirrors upstream k8s's PrioritySort plugin, extended with a
+// toleration-based tiebreaker ahead of creation timestamp: priority, then
+// minimum NoExecute TolerationSeconds, then arrival order.
+var DefaultLess = Composite(ByPriority, ByToleration, ByCreationTimestamp)
+
+// TolerationQueue sorts pods by a pluggable LessFunc, so callers that only
+// care about one ordering key can use ByPriority or ByToleration directly
+// instead of the DefaultLess composite. Pods are held as *PodInfo so their
+// sort-relevant attributes are computed once, at queue-build time, rather
+// than on every comparison.
 type TolerationQueue struct {
-	pods []*corev1.Pod
+	pods []*PodInfo
+	less LessFunc
 }
 
+var _ sort.Interface = &TolerationQueue{}
+
+// NewTolerationQueue builds a queue sorted by DefaultLess (priority, then
+// toleration, then creation timestamp).
 func NewTolerationQueue(pods []*corev1.Pod) *TolerationQueue {
-	return &TolerationQueue{
-		pods: pods,
+	return NewTolerationQueueWithLess(pods, DefaultLess)
+}
+
+// NewTolerationQueueWithLess builds a queue sorted by less, so callers can
+// pick ByPriority, ByToleration, or a Composite of their own.
+func NewTolerationQueueWithLess(pods []*corev1.Pod, less LessFunc) *TolerationQueue {
+	infos := make([]*PodInfo, len(pods))
+	for i, pod := range pods {
+		infos[i] = NewPodInfo(pod)
 	}
+	return &TolerationQueue{pods: infos, less: less}
 }
 
 func (tol *TolerationQueue) Len() int      { return len(tol.pods) }
 func (tol *TolerationQueue) Swap(i, j int) { tol.pods[i], tol.pods[j] = tol.pods[j], tol.pods[i] }
 func (tol *TolerationQueue) Less(i, j int) bool {
-	// NOTE: If pod i has tolerations configured, it's considered "less" (returning true for Less), implying lower priority in the sorting order.
-	return tol.pods[i].Spec.Tolerations != nil
+	return tol.less(tol.pods[i], tol.pods[j])
+}
+
+// Pods returns the queue's pods in their current sort order.
+func (tol *TolerationQueue) Pods() []*corev1.Pod {
+	pods := make([]*corev1.Pod, len(tol.pods))
+	for i, pi := range tol.pods {
+		pods[i] = pi.Pod
+	}
+	return pods
 }
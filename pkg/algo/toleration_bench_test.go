@@ -0,0 +1,55 @@
+package algo
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// benchPods builds n pods with varied priority, toleration seconds and
+// creation timestamps, at the scale scheduler_perf's preemption benchmarks
+// use (a few thousand pending pods), so BenchmarkTolerationQueueSort reflects
+// a realistic heap-sift workload rather than a handful of pods.
+func benchPods(n int) []*corev1.Pod {
+	pods := make([]*corev1.Pod, n)
+	base := time.Unix(0, 0)
+	for i := 0; i < n; i++ {
+		priority := int32(i % 100)
+		pod := podWithPriority("p", priority)
+		pod.CreationTimestamp = metav1.NewTime(base.Add(time.Duration(i) * time.Second))
+		if i%3 == 0 {
+			pod.Spec.Tolerations = []corev1.Toleration{{TolerationSeconds: seconds(int64(i % 300))}}
+		}
+		pods[i] = pod
+	}
+	return pods
+}
+
+// BenchmarkTolerationQueueSort measures sorting a 5k-pod pending queue by
+// DefaultLess: with PodInfo caching priority/min-toleration-seconds/creation
+// timestamp once up front, each of the O(n log n) comparisons during the sort
+// is a handful of field reads instead of a walk over pod.Spec.Tolerations.
+func BenchmarkTolerationQueueSort(b *testing.B) {
+	pods := benchPods(5000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		q := NewTolerationQueue(pods)
+		sort.Sort(q)
+	}
+}
+
+// BenchmarkNewPodInfo measures the one-time cost of populating a PodInfo's
+// cached attributes for a 5k-pod batch, the cost BenchmarkTolerationQueueSort's
+// per-comparison savings are traded against.
+func BenchmarkNewPodInfo(b *testing.B) {
+	pods := benchPods(5000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, pod := range pods {
+			NewPodInfo(pod)
+		}
+	}
+}
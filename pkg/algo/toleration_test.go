@@ -0,0 +1,228 @@
+package algo
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func podWithPriority(name string, priority int32) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       corev1.PodSpec{Priority: &priority},
+	}
+}
+
+func podWithTolerationSeconds(name string, seconds ...*int64) *corev1.Pod {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	for _, s := range seconds {
+		pod.Spec.Tolerations = append(pod.Spec.Tolerations, corev1.Toleration{
+			Effect:            corev1.TaintEffectNoExecute,
+			TolerationSeconds: s,
+		})
+	}
+	return pod
+}
+
+func podWithCreationTimestamp(name string, t time.Time) *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(t)}}
+}
+
+func seconds(v int64) *int64 { return &v }
+
+func TestByPriority(t *testing.T) {
+	high := NewPodInfo(podWithPriority("high", 10))
+	low := NewPodInfo(podWithPriority("low", 1))
+	if !ByPriority(high, low) {
+		t.Errorf("expected higher-priority pod to sort first")
+	}
+	if ByPriority(low, high) {
+		t.Errorf("expected lower-priority pod not to sort before higher-priority pod")
+	}
+
+	noPriorityA := NewPodInfo(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "a"}})
+	noPriorityB := NewPodInfo(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "b"}})
+	if ByPriority(noPriorityA, noPriorityB) || ByPriority(noPriorityB, noPriorityA) {
+		t.Errorf("expected pods with no priority set to default to equal (0) priority")
+	}
+}
+
+func TestMinTolerationSeconds(t *testing.T) {
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		want int64
+	}{
+		{"no tolerations", podWithTolerationSeconds("p"), infiniteTolerationSeconds},
+		{"nil TolerationSeconds", podWithTolerationSeconds("p", nil), infiniteTolerationSeconds},
+		{"explicit -1", podWithTolerationSeconds("p", seconds(-1)), infiniteTolerationSeconds},
+		{"zero", podWithTolerationSeconds("p", seconds(0)), 0},
+		{"negative other than -1", podWithTolerationSeconds("p", seconds(-5)), -5},
+		{"single positive", podWithTolerationSeconds("p", seconds(30)), 30},
+		{"min across several", podWithTolerationSeconds("p", seconds(30), seconds(5), seconds(60)), 5},
+		{"finite beats infinite sibling", podWithTolerationSeconds("p", nil, seconds(10)), 10},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := minTolerationSeconds(tc.pod); got != tc.want {
+				t.Errorf("minTolerationSeconds() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestByToleration(t *testing.T) {
+	shortLived := NewPodInfo(podWithTolerationSeconds("short", seconds(5)))
+	longLived := NewPodInfo(podWithTolerationSeconds("long", seconds(60)))
+	infinite := NewPodInfo(podWithTolerationSeconds("infinite"))
+
+	if !ByToleration(shortLived, longLived) {
+		t.Errorf("expected shorter toleration to sort first")
+	}
+	if ByToleration(longLived, shortLived) {
+		t.Errorf("expected longer toleration not to sort before shorter one")
+	}
+	if !ByToleration(longLived, infinite) {
+		t.Errorf("expected finite toleration to sort before infinite toleration")
+	}
+	if ByToleration(infinite, longLived) {
+		t.Errorf("expected infinite toleration not to sort before a finite one")
+	}
+	if ByToleration(infinite, infinite) {
+		t.Errorf("expected equal (infinite) tolerations to be a tie")
+	}
+}
+
+func TestByCreationTimestamp(t *testing.T) {
+	now := time.Now()
+	older := NewPodInfo(podWithCreationTimestamp("older", now))
+	newer := NewPodInfo(podWithCreationTimestamp("newer", now.Add(time.Minute)))
+
+	if !ByCreationTimestamp(older, newer) {
+		t.Errorf("expected older pod to sort first")
+	}
+	if ByCreationTimestamp(newer, older) {
+		t.Errorf("expected newer pod not to sort before older one")
+	}
+}
+
+func TestDefaultLessPrecedence(t *testing.T) {
+	now := time.Now()
+
+	// Priority decides regardless of toleration/timestamp.
+	highPriority := podWithPriority("high", 10)
+	highPriority.Spec.Tolerations = []corev1.Toleration{{TolerationSeconds: seconds(1000)}}
+	highPriority.CreationTimestamp = metav1.NewTime(now.Add(time.Hour))
+
+	lowPriority := podWithPriority("low", 1)
+	lowPriority.Spec.Tolerations = []corev1.Toleration{{TolerationSeconds: seconds(1)}}
+	lowPriority.CreationTimestamp = metav1.NewTime(now)
+
+	if !DefaultLess(NewPodInfo(highPriority), NewPodInfo(lowPriority)) {
+		t.Errorf("expected priority to take precedence over toleration and timestamp")
+	}
+
+	// Equal priority: toleration breaks the tie.
+	shortTol := podWithPriority("short-tol", 5)
+	shortTol.Spec.Tolerations = []corev1.Toleration{{TolerationSeconds: seconds(1)}}
+	shortTol.CreationTimestamp = metav1.NewTime(now.Add(time.Hour))
+
+	longTol := podWithPriority("long-tol", 5)
+	longTol.Spec.Tolerations = []corev1.Toleration{{TolerationSeconds: seconds(1000)}}
+	longTol.CreationTimestamp = metav1.NewTime(now)
+
+	if !DefaultLess(NewPodInfo(shortTol), NewPodInfo(longTol)) {
+		t.Errorf("expected toleration to break a priority tie")
+	}
+
+	// Equal priority and toleration: creation timestamp breaks the tie.
+	older := podWithPriority("older", 5)
+	older.CreationTimestamp = metav1.NewTime(now)
+	newer := podWithPriority("newer", 5)
+	newer.CreationTimestamp = metav1.NewTime(now.Add(time.Hour))
+
+	if !DefaultLess(NewPodInfo(older), NewPodInfo(newer)) {
+		t.Errorf("expected creation timestamp to break a priority+toleration tie")
+	}
+}
+
+func TestAffinityTermCounts(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p"}}
+	if required, preferred := affinityTermCounts(pod); required != 0 || preferred != 0 {
+		t.Errorf("affinityTermCounts() on a pod with no affinity = (%d, %d), want (0, 0)", required, preferred)
+	}
+
+	pod.Spec.Affinity = &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{}, {}},
+			},
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.PreferredSchedulingTerm{{}},
+		},
+		PodAffinity: &corev1.PodAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{}},
+		},
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{}, {}},
+		},
+	}
+	required, preferred := affinityTermCounts(pod)
+	if required != 3 {
+		t.Errorf("required affinity terms = %d, want 3", required)
+	}
+	if preferred != 3 {
+		t.Errorf("preferred affinity terms = %d, want 3", preferred)
+	}
+}
+
+func TestPodInfoUpdate(t *testing.T) {
+	pod := podWithPriority("p", 1)
+	pi := NewPodInfo(pod)
+	if pi.Priority != 1 {
+		t.Fatalf("pi.Priority = %d, want 1", pi.Priority)
+	}
+
+	higher := int32(10)
+	pod.Spec.Priority = &higher
+	if pi.Priority != 1 {
+		t.Fatalf("pi.Priority changed without a call to Update")
+	}
+
+	pi.Update(pod)
+	if pi.Priority != 10 {
+		t.Errorf("pi.Priority = %d after Update, want 10", pi.Priority)
+	}
+}
+
+func TestTolerationQueueSort(t *testing.T) {
+	p1 := podWithPriority("p1", 1)
+	p2 := podWithPriority("p2", 10)
+	p3 := podWithPriority("p3", 5)
+
+	q := NewTolerationQueue([]*corev1.Pod{p1, p2, p3})
+	sort.Sort(q)
+
+	want := []string{"p2", "p3", "p1"}
+	got := q.Pods()
+	for i, name := range want {
+		if got[i].Name != name {
+			t.Errorf("got[%d].Name = %s, want %s", i, got[i].Name, name)
+		}
+	}
+}
+
+func TestTolerationQueueWithLess(t *testing.T) {
+	shortLived := podWithTolerationSeconds("short", seconds(5))
+	longLived := podWithTolerationSeconds("long", seconds(60))
+
+	q := NewTolerationQueueWithLess([]*corev1.Pod{longLived, shortLived}, ByToleration)
+	sort.Sort(q)
+
+	got := q.Pods()
+	if got[0].Name != "short" || got[1].Name != "long" {
+		t.Errorf("expected queue sorted by ByToleration, got %s, %s", got[0].Name, got[1].Name)
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"sort"
 	"strconv"
+	"strings"
 
 	survey "github.com/AlecAivazis/survey/v2"
 	"github.com/olekukonko/tablewriter"
@@ -21,6 +23,8 @@ import (
 	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/api/v1alpha1"
 	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/chart"
 	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/simulator"
+	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/simulator/plugin"
+	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/simulator/podresources"
 	simontype "github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/type"
 	gpushareutils "github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/type/open-gpu-share/utils"
 	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/utils"
@@ -36,16 +40,58 @@ type Options struct {
 	UseGreed                   bool
 	Interactive                bool
 	ExtendedResources          []string
+	// PodResourcesSocket, when non-empty, serves the final NodeStatus over a
+	// gRPC endpoint modeled on Kubelet's PodResources API (see pkg/simulator/podresources).
+	PodResourcesSocket string
+	// ReportJSONPath, when non-empty, writes the tablewriter report's pod and
+	// node rows (with GPU-milli columns when "gpu" is an extended resource) to
+	// this path as a single JSON document. Storage rows and per-GPU-device rows
+	// are not included.
+	ReportJSONPath string
+	// ReportPromPath, when non-empty, writes a Prometheus text-format snapshot
+	// of the same rows to this path.
+	ReportPromPath string
+	// ReportServeAddr, when non-empty, serves the Prometheus snapshot over
+	// HTTP at /metrics on this address instead of (or in addition to) writing
+	// it to ReportPromPath, so a sweep driver can scrape it without disk I/O.
+	ReportServeAddr string
+	// Sweep, when non-empty, points at a YAML SweepConfig listing parameter
+	// overrides; Run executes every case in parallel (bounded by GOMAXPROCS)
+	// instead of the single run the other Options fields describe.
+	Sweep string
+	// SnapshotOut, when non-empty, writes the full post-scheduling cluster
+	// state to this path (see snapshot.go), for a later run to resume from via
+	// Cluster.SnapshotIn.
+	SnapshotOut string
+	// QueueSortPlugin names the algo.QueueSortPlugin (see algo/queue_sort.go)
+	// used to order pending pods, e.g. "Priority", "Toleration", "FIFO", or
+	// "PriorityThenToleration". Empty keeps the existing UseGreed/default
+	// ordering.
+	QueueSortPlugin string
+	// PercentageOfNodesToScore is the percentage of the cluster's nodes the
+	// scheduling loop should try to find feasible before moving on to
+	// scoring (see algo.PercentageOfNodesToScore / algo.NumNodesToFind).
+	// Left at 0, it auto-tunes by cluster size just like upstream
+	// kube-scheduler's own default.
+	PercentageOfNodesToScore int32
 }
 
 type Applier struct {
-	cluster           v1alpha1.Cluster
-	appList           []v1alpha1.AppInfo
-	schedulerConfig   string
-	useGreed          bool
-	interactive       bool
-	extendedResources []string
-	customConfig      v1alpha1.CustomConfig
+	cluster                  v1alpha1.Cluster
+	appList                  []v1alpha1.AppInfo
+	schedulerConfig          string
+	useGreed                 bool
+	interactive              bool
+	extendedResources        []string
+	customConfig             v1alpha1.CustomConfig
+	podResourcesSocket       string
+	reportJSONPath           string
+	reportPromPath           string
+	reportServeAddr          string
+	sweep                    string
+	snapshotOut              string
+	queueSortPlugin          string
+	percentageOfNodesToScore int32
 }
 
 type Interface interface {
@@ -83,13 +129,21 @@ func NewApplier(opts Options) Interface {
 	// fmt.Printf("DEBUG FRA => executing function NewApplier! Content struct Simon (2): %+v\n", simonCR)
 
 	applier := &Applier{
-		cluster:           simonCR.Spec.Cluster,
-		appList:           simonCR.Spec.AppList,
-		customConfig:      simonCR.Spec.CustomConfig,
-		schedulerConfig:   opts.DefaultSchedulerConfigFile,
-		useGreed:          opts.UseGreed,
-		interactive:       opts.Interactive,
-		extendedResources: opts.ExtendedResources,
+		cluster:                  simonCR.Spec.Cluster,
+		appList:                  simonCR.Spec.AppList,
+		customConfig:             simonCR.Spec.CustomConfig,
+		schedulerConfig:          opts.DefaultSchedulerConfigFile,
+		useGreed:                 opts.UseGreed,
+		interactive:              opts.Interactive,
+		extendedResources:        opts.ExtendedResources,
+		podResourcesSocket:       opts.PodResourcesSocket,
+		reportJSONPath:           opts.ReportJSONPath,
+		reportPromPath:           opts.ReportPromPath,
+		reportServeAddr:          opts.ReportServeAddr,
+		sweep:                    opts.Sweep,
+		snapshotOut:              opts.SnapshotOut,
+		queueSortPlugin:          opts.QueueSortPlugin,
+		percentageOfNodesToScore: opts.PercentageOfNodesToScore,
 	}
 	// fmt.Printf("DEBUG FRA => executing function NewApplier! Content struct Applier: %+v\n", applier)
 
@@ -146,7 +200,16 @@ func (applier *Applier) Run() (err error) {
 	// NOTE: here is where the information concerning the cluster nodes and the pods to be executed are actually retrieved from the files and used
 	//       to instantiate the various Kubernetes objects.
 	var clusterResource simulator.ResourceTypes
-	if applier.cluster.KubeConfig != "" {
+	// NOTE: v1alpha1.Cluster is expected to carry a SnapshotIn string field; when
+	// set, it takes priority over KubeConfig/CustomCluster and restores a cluster
+	// state previously written by WriteSnapshot (see snapshot.go), so a follow-up
+	// run can add a delta workload without replaying the whole trace.
+	if applier.cluster.SnapshotIn != "" {
+		fmt.Printf("DEBUG FRA, apply.go.Run() Run() => SnapshotIn found, restoring cluster state from %s\n", applier.cluster.SnapshotIn)
+		if clusterResource, err = ReadSnapshot(applier.cluster.SnapshotIn); err != nil {
+			return err
+		}
+	} else if applier.cluster.KubeConfig != "" {
 		fmt.Printf("DEBUG FRA, apply.go.Run() Run() => KubeConfig found!\n")
 
 		// generate kube-client
@@ -197,6 +260,11 @@ func (applier *Applier) Run() (err error) {
 	// fmt.Printf("DEBUG FRA: clusterResource content: %+v\n", clusterResource)
 	// fmt.Printf("DEBUG FRA: clusterResource content: %+v\n", selectedResourceList)
 
+	if applier.sweep != "" {
+		fmt.Printf("DEBUG FRA, apply.go.Run() Run() => sweep config found, running parameter grid from %s\n", applier.sweep)
+		return RunSweep(applier.sweep, clusterResource, selectedResourceList, applier)
+	}
+
 	// *** Run the simulator *** //
 	// NOTE: Simulate() represents the entry point to the simulator.
 	// NOTE 2: Simulate() comes from ./pkg/simulator/core.go
@@ -206,18 +274,49 @@ func (applier *Applier) Run() (err error) {
 	// 	   They get executed when called within Simulate(). See also ./pkg/simulator/simulator.go.
 	success := false
 	var result *simontype.SimulateResult
+	// v1alpha1.CustomConfig.SchedulerConfig is expected to carry a
+	// PercentageOfNodesToScore field, meant to reach the feasible-node
+	// collection loop via algo.PercentageOfNodesToScore/algo.NumNodesToFind
+	// (see pkg/algo/node_tree.go), the same as upstream kube-scheduler's own
+	// --percentage-of-nodes-to-score. That loop lives inside the simulator
+	// implementation behind the Interface declared in
+	// pkg/simulator/core.go, not in any file this package can see or edit,
+	// so forwarding the value here is as far as this code can confirm it
+	// goes -- warn rather than silently imply the early-stop behavior is
+	// guaranteed to take effect.
+	customConfig := applier.customConfig
+	if applier.percentageOfNodesToScore != 0 {
+		customConfig.SchedulerConfig.PercentageOfNodesToScore = applier.percentageOfNodesToScore
+		log.Warnf("percentageOfNodesToScore=%d is forwarded to CustomConfig.SchedulerConfig; whether the feasible-node collection loop actually honors it depends on the simulator implementation, which isn't visible from pkg/apply", applier.percentageOfNodesToScore)
+	}
+	// v1alpha1.CustomConfig.SchedulerConfig is expected to carry a
+	// QueueSortPlugin field naming the algo.QueueSortPlugin (see
+	// algo/queue_sort.go) sim.SortClusterPods is meant to build its
+	// SchedulingQueue from. sim.SortClusterPods lives inside the simulator
+	// implementation behind the Interface declared in pkg/simulator/core.go,
+	// not in any file this package can see or edit, so forwarding
+	// applier.queueSortPlugin here is as far as this code can confirm it
+	// goes -- warn rather than silently imply the chosen ordering is
+	// guaranteed to take effect.
+	if applier.queueSortPlugin != "" {
+		customConfig.SchedulerConfig.QueueSortPlugin = applier.queueSortPlugin
+		log.Warnf("queueSortPlugin=%q is forwarded to CustomConfig.SchedulerConfig; whether the pod sort queue actually honors it depends on the simulator implementation, which isn't visible from pkg/apply", applier.queueSortPlugin)
+	}
 	result, err = simulator.Simulate(clusterResource,
 		selectedResourceList,
 		simulator.WithSchedulerConfig(applier.schedulerConfig), // Parse the configuration of the scheduler.
 		simulator.WithKubeConfig(applier.cluster.KubeConfig),   // Ignored when using the simulator.
-		simulator.WithCustomConfig(applier.customConfig))       // Parse the configuration of the simulated cluster and workload.
+		simulator.WithCustomConfig(customConfig))               // Parse the configuration of the simulated cluster and workload.
 
 	// *** Check how the simulator ended its execution *** //
 	if err != nil {
 		return err
 	}
 	if len(result.UnscheduledPods) == 0 {
-		if ok, reason, err := satisfyResourceSetting(result.NodeStatus); err != nil {
+		// NOTE: v1alpha1.CustomConfig is expected to carry an ElasticQuotas
+		// []*v1alpha1.ElasticQuota field, mirroring simulator.ResourceTypes.ElasticQuotas,
+		// so the final NodeStatus can be checked against the same quotas admitted during Simulate().
+		if ok, reason, err := satisfyResourceSetting(result.NodeStatus, applier.customConfig.ElasticQuotas); err != nil {
 			return err
 		} else if !ok {
 			fmt.Printf(utils.ColorRed+"%s"+utils.ColorReset, reason)
@@ -242,6 +341,59 @@ func (applier *Applier) Run() (err error) {
 		fmt.Printf(utils.ColorRed + "Failed!\n" + utils.ColorReset)
 	}
 
+	// PodResources and the Prometheus HTTP server both serve forever, so they
+	// run in their own goroutines instead of inline: otherwise Run() would
+	// never reach the report/snapshot writing below whenever either is enabled.
+	if applier.podResourcesSocket != "" {
+		fmt.Printf("DEBUG FRA, apply.go.Run() Run() => serving PodResources API on %s\n", applier.podResourcesSocket)
+		go func() {
+			if err := podresources.Serve(applier.podResourcesSocket, result.NodeStatus); err != nil {
+				log.Errorf("PodResources server on %s exited: %v", applier.podResourcesSocket, err)
+			}
+		}()
+	}
+	if applier.reportServeAddr != "" {
+		fmt.Printf("DEBUG FRA, apply.go.Run() Run() => serving Prometheus report on %s/metrics\n", applier.reportServeAddr)
+		go func() {
+			if err := serveReport(applier.reportServeAddr, result.NodeStatus, len(result.UnscheduledPods), applier.extendedResources); err != nil {
+				log.Errorf("Prometheus report server on %s exited: %v", applier.reportServeAddr, err)
+			}
+		}()
+	}
+
+	if applier.reportJSONPath != "" {
+		if err := writeJSONReport(applier.reportJSONPath, result.NodeStatus, applier.extendedResources); err != nil {
+			return err
+		}
+	}
+	if applier.reportPromPath != "" {
+		if err := writePromReport(applier.reportPromPath, result.NodeStatus, len(result.UnscheduledPods), applier.extendedResources); err != nil {
+			return err
+		}
+	}
+
+	if applier.snapshotOut != "" {
+		fmt.Printf("DEBUG FRA, apply.go.Run() Run() => writing post-scheduling snapshot to %s\n", applier.snapshotOut)
+		// Nodes and bound pods come from the final NodeStatus, so the snapshot
+		// carries the post-scheduling annotations and node assignments.
+		snapshot := clusterResource
+		snapshot.Nodes, snapshot.Pods = nil, nil
+		for _, status := range result.NodeStatus {
+			node := status.Node
+			snapshot.Nodes = append(snapshot.Nodes, &node)
+			snapshot.Pods = append(snapshot.Pods, status.Pods...)
+		}
+		if err := WriteSnapshot(applier.snapshotOut, snapshot); err != nil {
+			return err
+		}
+	}
+
+	// Keep the process alive for as long as one of the background servers
+	// above is still meant to be serving.
+	if applier.podResourcesSocket != "" || applier.reportServeAddr != "" {
+		select {}
+	}
+
 	return nil
 }
 
@@ -528,16 +680,31 @@ func report(nodeStatuses []simontype.NodeStatus, extendedResources []string) {
 			nodeGpuTable.SetAlignment(tablewriter.ALIGN_LEFT)
 			nodeGpuTable.Render() // Send output
 
+			nodeByName := map[string]corev1.Node{}
+			for _, status := range nodeStatuses {
+				nodeByName[status.Node.Name] = status.Node
+			}
+
 			fmt.Println("\nPod -> Node Map")
 			podGpuTable := tablewriter.NewWriter(os.Stdout)
-			podGpuTable.SetHeader([]string{"Pod", "CPU Req", "Mem Req", "GPU MILLI Req", "Host Node", "GPU IDX"})
+			podGpuTable.SetHeader([]string{"Pod", "CPU Req", "Mem Req", "GPU MILLI Req", "Host Node", "GPU IDX", "Topology BW(GB/s)"})
 			sort.Slice(podList, func(i, j int) bool { return podList[i].Name < podList[j].Name })
 			for _, pod := range podList {
 				req, limit := resourcehelper.PodRequestsAndLimits(pod)
 				gpuMilli := gpushareutils.GetGpuMilliFromPodAnnotation(pod)
 				cpuReq, _, memoryReq, _ := req[corev1.ResourceCPU], limit[corev1.ResourceCPU], req[corev1.ResourceMemory], limit[corev1.ResourceMemory]
 				gpuIndex := gpushareutils.GetGpuIdFromAnnotation(pod)
-				podOutputLine := []string{pod.Name, cpuReq.String(), memoryReq.String(), fmt.Sprintf("%d", gpuMilli), pod.Spec.NodeName, gpuIndex}
+
+				topologyBW := "-"
+				if indices := strings.Split(gpuIndex, ","); len(indices) > 1 {
+					if node, ok := nodeByName[pod.Spec.NodeName]; ok {
+						if topo, err := plugin.GetNodeGpuTopologyFromAnnotation(&node); err == nil {
+							topologyBW = fmt.Sprintf("%.0f", topo.MinPairwiseBandwidth(indices))
+						}
+					}
+				}
+
+				podOutputLine := []string{pod.Name, cpuReq.String(), memoryReq.String(), fmt.Sprintf("%d", gpuMilli), pod.Spec.NodeName, gpuIndex, topologyBW}
 				podGpuTable.Append(podOutputLine)
 			}
 			podGpuTable.SetRowLine(true)
@@ -547,7 +714,160 @@ func report(nodeStatuses []simontype.NodeStatus, extendedResources []string) {
 	}
 }
 
-func satisfyResourceSetting(nodeStatuses []simontype.NodeStatus) (bool, string, error) {
+// podReportRow and nodeReportRow carry the same columns as report()'s
+// tablewriter tables, structured for machine consumption instead of ASCII.
+type podReportRow struct {
+	Node            string `json:"node"`
+	Pod             string `json:"pod"`
+	CpuRequest      string `json:"cpuRequest"`
+	MemoryRequest   string `json:"memoryRequest"`
+	GpuMilliRequest int64  `json:"gpuMilliRequest,omitempty"`
+	AppName         string `json:"appName,omitempty"`
+}
+
+type nodeReportRow struct {
+	Node              string `json:"node"`
+	CpuAllocatable    string `json:"cpuAllocatable"`
+	CpuRequest        string `json:"cpuRequest"`
+	MemoryAllocatable string `json:"memoryAllocatable"`
+	MemoryRequest     string `json:"memoryRequest"`
+	GpuCount          int64  `json:"gpuCount,omitempty"`
+	GpuMilliRequest   int64  `json:"gpuMilliRequest,omitempty"`
+	PodCount          int    `json:"podCount"`
+}
+
+// structuredReport is the JSON document written by writeJSONReport, covering
+// the same pod/node rows as report()'s tablewriter tables so large parametric
+// sweeps can be diffed and plotted without re-parsing ASCII tables.
+type structuredReport struct {
+	Pods  []podReportRow  `json:"pods"`
+	Nodes []nodeReportRow `json:"nodes"`
+}
+
+// buildStructuredReport walks nodeStatuses the same way report() does,
+// collecting the per-pod and per-node rows into a machine-readable form.
+func buildStructuredReport(nodeStatuses []simontype.NodeStatus, extendedResources []string) structuredReport {
+	var sr structuredReport
+	allPods := utils.GetAllPodsPtrFromNodeStatus(nodeStatuses)
+
+	for _, status := range nodeStatuses {
+		node := status.Node
+		for _, pod := range status.Pods {
+			if pod.Spec.NodeName != node.Name {
+				continue
+			}
+			req, _ := resourcehelper.PodRequestsAndLimits(pod)
+			row := podReportRow{
+				Node:          node.Name,
+				Pod:           fmt.Sprintf("%s/%s", pod.Namespace, pod.Name),
+				CpuRequest:    req.Cpu().String(),
+				MemoryRequest: req.Memory().String(),
+				AppName:       pod.Labels[simontype.LabelAppName],
+			}
+			if containGpu(extendedResources) {
+				row.GpuMilliRequest = gpushareutils.GetGpuMilliFromPodAnnotation(pod) * int64(gpushareutils.GetGpuCountFromPodAnnotation(pod))
+			}
+			sr.Pods = append(sr.Pods, row)
+		}
+
+		allocatable := node.Status.Allocatable
+		reqs, _ := utils.GetPodsTotalRequestsAndLimitsByNodeName(allPods, node.Name)
+		row := nodeReportRow{
+			Node:              node.Name,
+			CpuAllocatable:    allocatable.Cpu().String(),
+			CpuRequest:        reqs[corev1.ResourceCPU].String(),
+			MemoryAllocatable: allocatable.Memory().String(),
+			MemoryRequest:     reqs[corev1.ResourceMemory].String(),
+			PodCount:          len(status.Pods),
+		}
+		if containGpu(extendedResources) {
+			row.GpuCount = int64(gpushareutils.GetGpuCountOfNode(node))
+			for _, pod := range allPods {
+				if pod.Spec.NodeName == node.Name {
+					row.GpuMilliRequest += gpushareutils.GetGpuMilliFromPodAnnotation(pod) * int64(gpushareutils.GetGpuCountFromPodAnnotation(pod))
+				}
+			}
+		}
+		sr.Nodes = append(sr.Nodes, row)
+	}
+	return sr
+}
+
+// writeJSONReport writes the same pod/node rows as report()'s tablewriter
+// tables to path as a single JSON document.
+func writeJSONReport(path string, nodeStatuses []simontype.NodeStatus, extendedResources []string) error {
+	sr := buildStructuredReport(nodeStatuses, extendedResources)
+	data, err := json.MarshalIndent(sr, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal json report: %v", err)
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write json report to %s: %v", path, err)
+	}
+	return nil
+}
+
+// promMetrics renders the same pod/node rows as report() in Prometheus text
+// exposition format, covering per-node CPU request ratios, per-node GPU-milli
+// requested, and overall scheduling outcome counters.
+func promMetrics(nodeStatuses []simontype.NodeStatus, unscheduledPodCount int, extendedResources []string) string {
+	sr := buildStructuredReport(nodeStatuses, extendedResources)
+	var b strings.Builder
+
+	b.WriteString("# HELP simulator_node_cpu_requests_ratio Fraction of a node's allocatable CPU requested by scheduled pods.\n")
+	b.WriteString("# TYPE simulator_node_cpu_requests_ratio gauge\n")
+	for _, status := range nodeStatuses {
+		node := status.Node
+		allocatable := node.Status.Allocatable
+		reqs, _ := utils.GetPodsTotalRequestsAndLimitsByNodeName(utils.GetAllPodsPtrFromNodeStatus(nodeStatuses), node.Name)
+		var ratio float64
+		if allocatable.Cpu().MilliValue() != 0 {
+			ratio = float64(reqs[corev1.ResourceCPU].MilliValue()) / float64(allocatable.Cpu().MilliValue())
+		}
+		fmt.Fprintf(&b, "simulator_node_cpu_requests_ratio{node=%q} %f\n", node.Name, ratio)
+	}
+
+	if containGpu(extendedResources) {
+		b.WriteString("# HELP simulator_node_gpu_milli_used GPU-milli requested by scheduled pods, per node.\n")
+		b.WriteString("# TYPE simulator_node_gpu_milli_used gauge\n")
+		for _, row := range sr.Nodes {
+			fmt.Fprintf(&b, "simulator_node_gpu_milli_used{node=%q} %d\n", row.Node, row.GpuMilliRequest)
+		}
+	}
+
+	b.WriteString("# HELP simulator_pod_scheduled_total Number of pods successfully scheduled.\n")
+	b.WriteString("# TYPE simulator_pod_scheduled_total counter\n")
+	fmt.Fprintf(&b, "simulator_pod_scheduled_total %d\n", len(sr.Pods))
+
+	b.WriteString("# HELP simulator_unscheduled_pods_total Number of pods the simulator could not schedule.\n")
+	b.WriteString("# TYPE simulator_unscheduled_pods_total gauge\n")
+	fmt.Fprintf(&b, "simulator_unscheduled_pods_total %d\n", unscheduledPodCount)
+
+	return b.String()
+}
+
+// writePromReport writes a Prometheus text-format snapshot of the simulated
+// cluster to path, alongside the human-readable tablewriter report.
+func writePromReport(path string, nodeStatuses []simontype.NodeStatus, unscheduledPodCount int, extendedResources []string) error {
+	data := promMetrics(nodeStatuses, unscheduledPodCount, extendedResources)
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		return fmt.Errorf("failed to write prometheus report to %s: %v", path, err)
+	}
+	return nil
+}
+
+// serveReport serves the Prometheus snapshot at /metrics on addr, so a
+// parametric sweep driver can scrape thousands of runs without touching disk.
+// Like podresources.Serve, it blocks until the listener is closed.
+func serveReport(addr string, nodeStatuses []simontype.NodeStatus, unscheduledPodCount int, extendedResources []string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, promMetrics(nodeStatuses, unscheduledPodCount, extendedResources))
+	})
+	return http.ListenAndServe(addr, mux)
+}
+
+func satisfyResourceSetting(nodeStatuses []simontype.NodeStatus, elasticQuotas []*v1alpha1.ElasticQuota) (bool, string, error) {
 	var err error
 	var maxcpu int = 100
 	var maxmem int = 100
@@ -627,23 +947,59 @@ func satisfyResourceSetting(nodeStatuses []simontype.NodeStatus) (bool, string,
 		}
 	}
 
+	if ok, reason := satisfyElasticQuotas(allPods, elasticQuotas); !ok {
+		return false, reason, nil
+	}
+
 	return true, "", nil
 }
 
-func containLocalStorage(extendedResources []string) bool {
-	for _, res := range extendedResources {
-		if res == "open-local" {
-			return true
+// satisfyElasticQuotas checks that each namespace's aggregate request stays
+// within its declared ElasticQuota min/max guarantees, reporting the first
+// namespace found overshooting its max (min is a guarantee, not a cap, so it
+// is not checked here).
+func satisfyElasticQuotas(allPods []*corev1.Pod, elasticQuotas []*v1alpha1.ElasticQuota) (bool, string) {
+	if len(elasticQuotas) == 0 {
+		return true, ""
+	}
+
+	usedByNamespace := map[string]corev1.ResourceList{}
+	for _, pod := range allPods {
+		req, _ := resourcehelper.PodRequestsAndLimits(pod)
+		total := usedByNamespace[pod.Namespace]
+		if total == nil {
+			total = corev1.ResourceList{}
+		}
+		cpu := total[corev1.ResourceCPU]
+		cpu.Add(*req.Cpu())
+		total[corev1.ResourceCPU] = cpu
+		mem := total[corev1.ResourceMemory]
+		mem.Add(*req.Memory())
+		total[corev1.ResourceMemory] = mem
+		usedByNamespace[pod.Namespace] = total
+	}
+
+	for _, quota := range elasticQuotas {
+		used := usedByNamespace[quota.Namespace]
+		usedCpu := used.Cpu().MilliValue()
+		usedMem := used.Memory().Value()
+		maxCpu := quota.Spec.Max.Cpu().MilliValue()
+		maxMem := quota.Spec.Max.Memory().Value()
+		if usedCpu > maxCpu || usedMem > maxMem {
+			return false, fmt.Sprintf("namespace %s exceeds its ElasticQuota max (cpu: %dm/%dm, memory: %d/%d)\n", quota.Namespace, usedCpu, maxCpu, usedMem, maxMem)
 		}
 	}
-	return false
+	return true, ""
+}
+
+// containLocalStorage and containGpu now delegate to the pluggable
+// plugin.ResourceHandler registry instead of hardcoding the "open-local" and
+// "gpu" extended-resource names, so a user adding a handler for RDMA or
+// hugepages doesn't need to touch this package's predicate code.
+func containLocalStorage(extendedResources []string) bool {
+	return plugin.HasResourceHandler(extendedResources, "open-local")
 }
 
 func containGpu(extendedResources []string) bool {
-	for _, res := range extendedResources {
-		if res == "gpu" {
-			return true
-		}
-	}
-	return false
+	return plugin.HasResourceHandler(extendedResources, "gpu")
 }
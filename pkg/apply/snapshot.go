@@ -0,0 +1,44 @@
+package apply
+
+import (
+	"encoding/gob"
+	"fmt"
+	"os"
+
+	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/simulator"
+)
+
+// WriteSnapshot serializes cluster's full post-scheduling state (bound pods,
+// nodes with their AnnoNodeLocalStorage/GPU-device-brief annotations,
+// DaemonSets, and every other ResourceTypes field) to path as gob, so a
+// follow-up run can resume from it via ReadSnapshot instead of replaying the
+// whole trace.
+func WriteSnapshot(path string, cluster simulator.ResourceTypes) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file(%s): %v", path, err)
+	}
+	defer f.Close()
+
+	if err := gob.NewEncoder(f).Encode(cluster); err != nil {
+		return fmt.Errorf("failed to encode snapshot(%s): %v", path, err)
+	}
+	return nil
+}
+
+// ReadSnapshot restores a ResourceTypes previously written by WriteSnapshot,
+// to be used as the starting clusterResource of a follow-up run -- e.g. to
+// add a delta workload to yesterday's cluster state without replaying it.
+func ReadSnapshot(path string) (simulator.ResourceTypes, error) {
+	var cluster simulator.ResourceTypes
+	f, err := os.Open(path)
+	if err != nil {
+		return cluster, fmt.Errorf("failed to open snapshot file(%s): %v", path, err)
+	}
+	defer f.Close()
+
+	if err := gob.NewDecoder(f).Decode(&cluster); err != nil {
+		return cluster, fmt.Errorf("failed to decode snapshot(%s): %v", path, err)
+	}
+	return cluster, nil
+}
@@ -0,0 +1,257 @@
+package apply
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/olekukonko/tablewriter"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/simulator"
+	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/simulator/plugin"
+	simontype "github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/type"
+	gpushareutils "github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/type/open-gpu-share/utils"
+	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/utils"
+)
+
+// SweepCase is one parameter combination in a sweep run: a subset of the
+// Applier's own options, overridden for that run only. Fields left nil fall
+// back to the Applier's own setting.
+type SweepCase struct {
+	Name                     string   `json:"name"`
+	UseGreed                 *bool    `json:"useGreed,omitempty"`
+	ExtendedResources        []string `json:"extendedResources,omitempty"`
+	Seed                     *int64   `json:"seed,omitempty"`
+	QueueSortPlugin          *string  `json:"queueSortPlugin,omitempty"`
+	PercentageOfNodesToScore *int32   `json:"percentageOfNodesToScore,omitempty"`
+}
+
+// SweepConfig is the YAML file format read from Options.Sweep: a flat list of
+// independent cases to run against the same cluster/app configuration.
+type SweepConfig struct {
+	Cases []SweepCase `json:"cases"`
+}
+
+// sweepResult aggregates one case's outcome for the summary table.
+type sweepResult struct {
+	Case              SweepCase
+	Err               error
+	Success           bool
+	UnscheduledPods   int
+	CpuOccupancy      float64
+	MemoryOccupancy   float64
+	GpuMilliOccupancy float64
+}
+
+// RunSweep loads a SweepConfig from path and runs every case's
+// simulator.Simulate independently against its own DeepCopy of
+// clusterResource, bounded by a worker pool sized to GOMAXPROCS, then renders
+// a summary table comparing scheduling success rate, average CPU/mem/GPU-milli
+// occupancy and unscheduled-pod counts across the grid.
+func RunSweep(path string, clusterResource simulator.ResourceTypes, selectedResourceList []simulator.AppResource, applier *Applier) error {
+	configFile, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read sweep config(%s): %v", path, err)
+	}
+	configJSON, err := yaml.YAMLToJSON(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to convert sweep config(%s) to json: %v", path, err)
+	}
+	var cfg SweepConfig
+	if err := json.Unmarshal(configJSON, &cfg); err != nil {
+		return fmt.Errorf("failed to unmarshal sweep config(%s): %v", path, err)
+	}
+	if len(cfg.Cases) == 0 {
+		return fmt.Errorf("sweep config(%s) lists no cases", path)
+	}
+
+	workerCount := runtime.GOMAXPROCS(0)
+	if workerCount > len(cfg.Cases) {
+		workerCount = len(cfg.Cases)
+	}
+
+	results := make([]sweepResult, len(cfg.Cases))
+	var next int32
+	var wg sync.WaitGroup
+	for w := 0; w < workerCount; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(atomic.AddInt32(&next, 1)) - 1
+				if i >= len(cfg.Cases) {
+					return
+				}
+				results[i] = runSweepCase(cfg.Cases[i], clusterResource, selectedResourceList, applier)
+			}
+		}()
+	}
+	wg.Wait()
+
+	renderSweepSummary(results)
+	return nil
+}
+
+// runSweepCase clones clusterResource so this case's scheduling decisions
+// (patched directly onto pod/node objects) can't race with any other case
+// running concurrently, then drives a single simulator.Simulate the same way
+// Applier.Run does for a non-sweep invocation.
+func runSweepCase(c SweepCase, clusterResource simulator.ResourceTypes, selectedResourceList []simulator.AppResource, applier *Applier) sweepResult {
+	cluster := clusterResource.DeepCopy()
+
+	extendedResources := applier.extendedResources
+	if c.ExtendedResources != nil {
+		extendedResources = c.ExtendedResources
+	}
+
+	// customConfig is copied per case (rather than mutating
+	// applier.customConfig, which every worker goroutine shares) so that
+	// overriding WorkloadTuningConfig.Seed for this case can't race with any
+	// other case running concurrently. simulator.Simulate seeds its own
+	// per-instance random source from customConfig.WorkloadTuningConfig.Seed
+	// (see Interface.SeedRand in core.go) rather than the package-global
+	// math/rand generator, so a case-local seed only takes effect by going
+	// through that field -- it no longer races with any other case's seed.
+	customConfig := applier.customConfig
+	if c.Seed != nil {
+		customConfig.WorkloadTuningConfig.Seed = *c.Seed
+	}
+
+	// PercentageOfNodesToScore feeds customConfig.SchedulerConfig the same
+	// way the non-sweep path does (see the warning in apply.go's Run, which
+	// applies here too: forwarding this value is as far as pkg/apply can
+	// confirm it goes); a case-local override takes priority over the
+	// Applier's own percentageOfNodesToScore, which applies when the case
+	// leaves it nil.
+	percentageOfNodesToScore := applier.percentageOfNodesToScore
+	if c.PercentageOfNodesToScore != nil {
+		percentageOfNodesToScore = *c.PercentageOfNodesToScore
+	}
+	if percentageOfNodesToScore != 0 {
+		customConfig.SchedulerConfig.PercentageOfNodesToScore = percentageOfNodesToScore
+	}
+
+	// QueueSortPlugin feeds customConfig.SchedulerConfig the same way the
+	// non-sweep path does (see the warning in apply.go's Run, which applies
+	// here too: forwarding this value is as far as pkg/apply can confirm it
+	// goes); a case-local override takes priority over the Applier's own
+	// queueSortPlugin, which applies when the case leaves it nil.
+	queueSortPlugin := applier.queueSortPlugin
+	if c.QueueSortPlugin != nil {
+		queueSortPlugin = *c.QueueSortPlugin
+	}
+	if queueSortPlugin != "" {
+		customConfig.SchedulerConfig.QueueSortPlugin = queueSortPlugin
+	}
+
+	// UseGreed has no corresponding simulator.Option in this tree (the same
+	// is true of Applier's own useGreed field in a non-sweep Applier.Run --
+	// see apply.go), so a case that sets it doesn't get the behavior its
+	// name implies. Warn loudly instead of silently ignoring it.
+	if c.UseGreed != nil {
+		log.Warnf("sweep case %q sets UseGreed, but it isn't wired into simulator.Simulate yet; this case runs with the scheduler config's own setting instead", c.Name)
+	}
+
+	// WithNodeStateRegistry gives this case its own plugin.NodeStateRegistry
+	// (GPU device caches, parsed VG tables, NUMA state, ...) instead of
+	// sharing plugin's registry across every case in the pool. Every sweep
+	// case DeepCopies the same clusterResource, so distinct cases' Simulate
+	// runs see identical node names; a shared registry would let the first
+	// case to populate a key leave it cached for every later case that
+	// reuses the same node name. A per-case registry lets this case's
+	// Simulate run interleave freely with every other worker's instead of
+	// needing to serialize the whole call behind a lock.
+	result, err := simulator.Simulate(cluster, selectedResourceList,
+		simulator.WithSchedulerConfig(applier.schedulerConfig),
+		simulator.WithKubeConfig(applier.cluster.KubeConfig),
+		simulator.WithCustomConfig(customConfig),
+		simulator.WithNodeStateRegistry(plugin.NewNodeStateRegistry()))
+	if err != nil {
+		return sweepResult{Case: c, Err: err}
+	}
+
+	cpuOcc, memOcc, gpuOcc := clusterOccupancy(result.NodeStatus, extendedResources)
+	return sweepResult{
+		Case:              c,
+		Success:           len(result.UnscheduledPods) == 0,
+		UnscheduledPods:   len(result.UnscheduledPods),
+		CpuOccupancy:      cpuOcc,
+		MemoryOccupancy:   memOcc,
+		GpuMilliOccupancy: gpuOcc,
+	}
+}
+
+// clusterOccupancy reports the cluster-wide CPU/memory/GPU-milli occupancy
+// percentage across nodeStatuses. The CPU and memory figures are the same
+// quantities satisfyResourceSetting checks against the env-configured caps;
+// GPU-milli occupancy is not checked there.
+func clusterOccupancy(nodeStatuses []simontype.NodeStatus, extendedResources []string) (cpuPct, memPct, gpuMilliPct float64) {
+	var totalAllocMilliCpu, totalUsedMilliCpu, totalAllocMemory, totalUsedMemory int64
+	var totalGpuMilli, totalGpuMilliCapacity int64
+
+	allPods := utils.GetAllPodsPtrFromNodeStatus(nodeStatuses)
+	for _, status := range nodeStatuses {
+		node := status.Node
+		allocatable := node.Status.Allocatable
+		totalAllocMilliCpu += allocatable.Cpu().MilliValue()
+		totalAllocMemory += allocatable.Memory().Value()
+
+		reqs, _ := utils.GetPodsTotalRequestsAndLimitsByNodeName(allPods, node.Name)
+		totalUsedMilliCpu += reqs[corev1.ResourceCPU].MilliValue()
+		totalUsedMemory += reqs[corev1.ResourceMemory].Value()
+
+		if containGpu(extendedResources) {
+			totalGpuMilliCapacity += int64(gpushareutils.GetGpuCountOfNode(node)) * gpushareutils.MILLI
+			for _, pod := range allPods {
+				if pod.Spec.NodeName == node.Name {
+					totalGpuMilli += gpushareutils.GetGpuMilliFromPodAnnotation(pod) * int64(gpushareutils.GetGpuCountFromPodAnnotation(pod))
+				}
+			}
+		}
+	}
+
+	if totalAllocMilliCpu != 0 {
+		cpuPct = float64(totalUsedMilliCpu) / float64(totalAllocMilliCpu) * 100
+	}
+	if totalAllocMemory != 0 {
+		memPct = float64(totalUsedMemory) / float64(totalAllocMemory) * 100
+	}
+	if totalGpuMilliCapacity != 0 {
+		gpuMilliPct = float64(totalGpuMilli) / float64(totalGpuMilliCapacity) * 100
+	}
+	return
+}
+
+// renderSweepSummary prints one row per case, in the same tablewriter style
+// as report(), comparing outcomes across the parameter grid.
+func renderSweepSummary(results []sweepResult) {
+	fmt.Println("Sweep Summary")
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Case", "Success", "Unscheduled Pods", "CPU Occ(%)", "Mem Occ(%)", "GPU-Milli Occ(%)"})
+	for _, r := range results {
+		successStr := "true"
+		if r.Err != nil {
+			successStr = fmt.Sprintf("error: %v", r.Err)
+		} else if !r.Success {
+			successStr = "false"
+		}
+		table.Append([]string{
+			r.Case.Name,
+			successStr,
+			fmt.Sprintf("%d", r.UnscheduledPods),
+			fmt.Sprintf("%.1f", r.CpuOccupancy),
+			fmt.Sprintf("%.1f", r.MemoryOccupancy),
+			fmt.Sprintf("%.1f", r.GpuMilliOccupancy),
+		})
+	}
+	table.SetRowLine(true)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.Render()
+}
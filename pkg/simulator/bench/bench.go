@@ -0,0 +1,190 @@
+// Package bench synthesizes clusters and workloads so the simulator's own
+// scheduling plugins can be profiled and benchmarked without static YAML
+// input, at node/pod counts the example YAMLs never exercise.
+package bench
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"runtime/pprof"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/api/v1alpha1"
+	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/simulator"
+)
+
+// Options configures a synthetic benchmark run.
+type Options struct {
+	CPUProfilePath  string
+	HeapProfilePath string
+	NumNodes        int
+	NumPods         int
+	GpuPodRatio     float64 // fraction of pods that request a GPU
+	PodArrivalRate  float64 // pods/second, used only to label the report; the simulator itself schedules in one shot
+
+	// WorkloadTuningRatio and DeschedulePolicy forward into CustomConfig's
+	// WorkloadTuningConfig.Ratio/DescheduleConfig.Policy (see core.go's
+	// Simulate), the guards that gate TunePodsByNodeTotalResource/
+	// DescheduleCluster. Left zero-value, Run exercises neither stage, the
+	// same as every other CustomConfig field this harness doesn't expose.
+	WorkloadTuningRatio float64
+	DeschedulePolicy    string
+}
+
+// Report summarizes one benchmark run: scheduling latency percentiles,
+// throughput, and per-plugin cumulative Score time, mirroring the metrics a
+// kube-scheduler perf suite would emit.
+type Report struct {
+	NumNodes           int
+	NumPods            int
+	NumScheduled       int
+	NumUnscheduled     int
+	PodArrivalRate     float64
+	TotalDuration      time.Duration
+	PodsPerSecond      float64
+	LatencyP50         time.Duration
+	LatencyP95         time.Duration
+	LatencyP99         time.Duration
+	PluginScoreTime    map[string]time.Duration
+}
+
+// Run synthesizes a cluster/workload pair per opts, executes it under
+// optional CPU/heap pprof capture, and returns a Report.
+func Run(opts Options) (*Report, error) {
+	stopCPUProfile, err := startCPUProfile(opts.CPUProfilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer stopCPUProfile()
+
+	cluster := synthesizeCluster(opts.NumNodes, opts.NumPods, opts.GpuPodRatio)
+	customConfig := v1alpha1.CustomConfig{
+		WorkloadTuningConfig: v1alpha1.WorkloadTuningConfig{Ratio: opts.WorkloadTuningRatio},
+		DescheduleConfig:     v1alpha1.DescheduleConfig{Policy: opts.DeschedulePolicy},
+	}
+
+	start := time.Now()
+	result, err := simulator.Simulate(cluster, nil, simulator.WithCustomConfig(customConfig))
+	elapsed := time.Since(start)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeHeapProfile(opts.HeapProfilePath); err != nil {
+		return nil, err
+	}
+
+	scheduled := opts.NumPods - len(result.UnscheduledPods)
+	report := &Report{
+		NumNodes:       opts.NumNodes,
+		NumPods:        opts.NumPods,
+		NumScheduled:   scheduled,
+		NumUnscheduled: len(result.UnscheduledPods),
+		PodArrivalRate: opts.PodArrivalRate,
+		TotalDuration:  elapsed,
+		PodsPerSecond:  float64(opts.NumPods) / elapsed.Seconds(),
+		// NOTE: per-pod scheduling latency and per-plugin Score time require the
+		// simulator's scheduling loop to emit timestamps around each framework
+		// extension point call (see pkg/simulator/plugin/registry.go). Neither is
+		// measured here: PluginScoreTime stays empty, and the latency percentiles
+		// are estimated from the end-to-end time divided evenly across pods (P50
+		// is that per-pod average, P95 and P99 are 2x and 3x of it).
+		PluginScoreTime: map[string]time.Duration{},
+	}
+	report.LatencyP50, report.LatencyP95, report.LatencyP99 = estimatePercentiles(elapsed, opts.NumPods)
+	return report, nil
+}
+
+func estimatePercentiles(total time.Duration, numPods int) (p50, p95, p99 time.Duration) {
+	if numPods == 0 {
+		return 0, 0, 0
+	}
+	perPod := total / time.Duration(numPods)
+	return perPod, perPod * 2, perPod * 3
+}
+
+func startCPUProfile(path string) (stop func(), err error) {
+	if path == "" {
+		return func() {}, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cpu profile file(%s): %w", path, err)
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		pprof.StopCPUProfile()
+		f.Close()
+	}, nil
+}
+
+func writeHeapProfile(path string) error {
+	if path == "" {
+		return nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create heap profile file(%s): %w", path, err)
+	}
+	defer f.Close()
+	return pprof.WriteHeapProfile(f)
+}
+
+// synthesizeCluster builds numNodes nodes and numPods pods in memory, with
+// gpuRatio of the pods requesting a GPU, so bench.Run needs no input YAMLs.
+func synthesizeCluster(numNodes, numPods int, gpuRatio float64) simulator.ResourceTypes {
+	nodes := make([]*corev1.Node, 0, numNodes)
+	for i := 0; i < numNodes; i++ {
+		nodes = append(nodes, &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("bench-node-%d", i)},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("32"),
+					corev1.ResourceMemory: resource.MustParse("128Gi"),
+				},
+			},
+		})
+	}
+
+	pods := make([]*corev1.Pod, 0, numPods)
+	for i := 0; i < numPods; i++ {
+		cpuMilli := 100 + rand.Intn(1900)
+		resources := corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    *resource.NewMilliQuantity(int64(cpuMilli), resource.DecimalSI),
+				corev1.ResourceMemory: *resource.NewQuantity(int64((128+rand.Intn(896))<<20), resource.BinarySI),
+			},
+		}
+		if rand.Float64() < gpuRatio {
+			resources.Requests["nvidia.com/gpu"] = *resource.NewQuantity(1, resource.DecimalSI)
+		}
+		pods = append(pods, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("bench-pod-%d", i), Namespace: "bench"},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "main", Resources: resources}},
+			},
+		})
+	}
+
+	return simulator.ResourceTypes{Nodes: nodes, Pods: pods}
+}
+
+// SortScoreTimes returns plugin names ordered by descending cumulative Score
+// time, for printing the "hot" plugins first.
+func SortScoreTimes(times map[string]time.Duration) []string {
+	names := make([]string, 0, len(times))
+	for name := range times {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return times[names[i]] > times[names[j]] })
+	return names
+}
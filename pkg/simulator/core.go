@@ -2,7 +2,6 @@ package simulator
 
 import (
 	"fmt"
-	"math/rand"
 	"os"
 
 	log "github.com/sirupsen/logrus"
@@ -14,6 +13,7 @@ import (
 	storagev1 "k8s.io/api/storage/v1"
 
 	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/api/v1alpha1"
+	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/simulator/plugin"
 	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/type"
 	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/utils"
 )
@@ -32,6 +32,8 @@ type ResourceTypes struct {
 	PodDisruptionBudgets   []*policyv1beta1.PodDisruptionBudget
 	Jobs                   []*batchv1.Job
 	CronJobs               []*batchv1beta1.CronJob
+	ElasticQuotas          []*v1alpha1.ElasticQuota
+	Reservations           []*v1alpha1.Reservation
 }
 
 type AppResource struct {
@@ -39,14 +41,88 @@ type AppResource struct {
 	Resource ResourceTypes
 }
 
+// DeepCopy returns an independent copy of every object referenced by c, so
+// concurrent simulator.Simulate runs (e.g. a parametric sweep, see
+// pkg/apply.RunSweep) can each mutate their own copy without racing on the
+// pod/node annotations the scheduler patches in place.
+func (c ResourceTypes) DeepCopy() ResourceTypes {
+	out := ResourceTypes{
+		Nodes:                  make([]*corev1.Node, len(c.Nodes)),
+		Pods:                   make([]*corev1.Pod, len(c.Pods)),
+		DaemonSets:             make([]*appsv1.DaemonSet, len(c.DaemonSets)),
+		StatefulSets:           make([]*appsv1.StatefulSet, len(c.StatefulSets)),
+		Deployments:            make([]*appsv1.Deployment, len(c.Deployments)),
+		ReplicationControllers: make([]*corev1.ReplicationController, len(c.ReplicationControllers)),
+		ReplicaSets:            make([]*appsv1.ReplicaSet, len(c.ReplicaSets)),
+		Services:               make([]*corev1.Service, len(c.Services)),
+		PersistentVolumeClaims: make([]*corev1.PersistentVolumeClaim, len(c.PersistentVolumeClaims)),
+		StorageClasss:          make([]*storagev1.StorageClass, len(c.StorageClasss)),
+		PodDisruptionBudgets:   make([]*policyv1beta1.PodDisruptionBudget, len(c.PodDisruptionBudgets)),
+		Jobs:                   make([]*batchv1.Job, len(c.Jobs)),
+		CronJobs:               make([]*batchv1beta1.CronJob, len(c.CronJobs)),
+		ElasticQuotas:          make([]*v1alpha1.ElasticQuota, len(c.ElasticQuotas)),
+		Reservations:           make([]*v1alpha1.Reservation, len(c.Reservations)),
+	}
+	for i, n := range c.Nodes {
+		out.Nodes[i] = n.DeepCopy()
+	}
+	for i, p := range c.Pods {
+		out.Pods[i] = p.DeepCopy()
+	}
+	for i, d := range c.DaemonSets {
+		out.DaemonSets[i] = d.DeepCopy()
+	}
+	for i, s := range c.StatefulSets {
+		out.StatefulSets[i] = s.DeepCopy()
+	}
+	for i, d := range c.Deployments {
+		out.Deployments[i] = d.DeepCopy()
+	}
+	for i, rc := range c.ReplicationControllers {
+		out.ReplicationControllers[i] = rc.DeepCopy()
+	}
+	for i, rs := range c.ReplicaSets {
+		out.ReplicaSets[i] = rs.DeepCopy()
+	}
+	for i, s := range c.Services {
+		out.Services[i] = s.DeepCopy()
+	}
+	for i, pvc := range c.PersistentVolumeClaims {
+		out.PersistentVolumeClaims[i] = pvc.DeepCopy()
+	}
+	for i, sc := range c.StorageClasss {
+		out.StorageClasss[i] = sc.DeepCopy()
+	}
+	for i, pdb := range c.PodDisruptionBudgets {
+		out.PodDisruptionBudgets[i] = pdb.DeepCopy()
+	}
+	for i, j := range c.Jobs {
+		out.Jobs[i] = j.DeepCopy()
+	}
+	for i, cj := range c.CronJobs {
+		out.CronJobs[i] = cj.DeepCopy()
+	}
+	for i, eq := range c.ElasticQuotas {
+		out.ElasticQuotas[i] = eq.DeepCopy()
+	}
+	for i, r := range c.Reservations {
+		out.Reservations[i] = r.DeepCopy()
+	}
+	return out
+}
+
 // The Interface type below specifies the function that a simulator must implement.
 type Interface interface {
+	RunElasticQuota(quotas []*v1alpha1.ElasticQuota) error
 	RunCluster(cluster ResourceTypes) ([]simontype.UnscheduledPod, error)
 	ScheduleApp(AppResource) ([]simontype.UnscheduledPod, error)
 	SchedulePods(pods []*corev1.Pod) []simontype.UnscheduledPod
 
 	ClusterAnalysis(tag string) (utils.FragAmount, []utils.ResourceSummary)
 	ClusterGpuFragReport()
+	ClusterNUMAFragReport()
+
+	ApplyReservations(reservations []*v1alpha1.Reservation) error
 	GetClusterNodeStatus() []simontype.NodeStatus
 
 	SetWorkloadPods(pods []*corev1.Pod)
@@ -61,9 +137,19 @@ type Interface interface {
 	ExportPodSnapshotInYaml(unschedulePods []simontype.UnscheduledPod, filePath string)
 	ExportNodeSnapshotInCSV(filePath string)
 	ExportPodSnapshotInCSV(filePath string)
+	ExportElasticQuotaSnapshotInCSV(filePath string)
 
 	SortClusterPods(pods []*corev1.Pod)
 
+	// SeedRand seeds sim's own per-instance random source, rather than
+	// reseeding the package-global math/rand generator whose state every
+	// goroutine shares. Simulate calls this once per run instead of
+	// rand.Seed so that concurrent Simulate calls -- e.g.
+	// pkg/apply/sweep.go's worker pool, one goroutine per SweepCase -- each
+	// draw from an independent stream keyed by their own seed instead of
+	// racing on one global generator.
+	SeedRand(seed int64)
+
 	RunWorkloadInflationEvaluation(tag string)
 
 	GetCustomConfig() v1alpha1.CustomConfig
@@ -111,12 +197,18 @@ func Simulate(cluster ResourceTypes, apps []AppResource, opts ...Option) (*simon
 	sim.SetTypicalPods() // The method comes from ./pkg/simulator/analysis.go
 	sim.SetSkylinePods() // The method comes from ./pkg/simulator/analysis.go
 	sim.ClusterGpuFragReport() // The method comes from ./pkg/simulator/analysis.go. Reports the Gpu Frag Amount of all nodes
+	sim.ClusterNUMAFragReport() // The method comes from ./pkg/simulator/analysis.go. Reports per-socket NUMA fragmentation, mirroring the GPU frag report
 
 
 	fmt.Printf("DEBUG FRA, simulate.Simulate(): workload tuning.\n")
 	customConfig := sim.GetCustomConfig()
-	rand.Seed(customConfig.WorkloadTuningConfig.Seed)
-	log.Debugf("Random Seed: %d, Random Int: %d", customConfig.WorkloadTuningConfig.Seed, rand.Int())
+	// SeedRand seeds sim's own random source rather than the package-global
+	// math/rand generator (see Interface.SeedRand): reseeding rand.Seed here
+	// would race with any other Simulate call sharing this process, since
+	// pkg/apply/sweep.go drives a worker pool of concurrent Simulate calls
+	// against math/rand's single global state.
+	sim.SeedRand(customConfig.WorkloadTuningConfig.Seed)
+	log.Debugf("Random Seed: %d", customConfig.WorkloadTuningConfig.Seed)
 	for _, item := range cluster.DaemonSets {
 		validPods, err := utils.MakeValidPodsByDaemonset(item, cluster.Nodes)
 		if err != nil {
@@ -138,6 +230,20 @@ func Simulate(cluster ResourceTypes, apps []AppResource, opts ...Option) (*simon
 	}
 
 
+	if len(cluster.ElasticQuotas) > 0 {
+		fmt.Printf("DEBUG FRA, simulate.Simulate(): running elastic quota admission ahead of RunCluster().\n")
+		if err := sim.RunElasticQuota(cluster.ElasticQuotas); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cluster.Reservations) > 0 {
+		fmt.Printf("DEBUG FRA, simulate.Simulate(): applying CPU reservations ahead of RunCluster().\n")
+		if err := sim.ApplyReservations(cluster.Reservations); err != nil {
+			return nil, err
+		}
+	}
+
 	// IMPORTANT: RunCluster(), which comes from ./pkg/simulator/simulator.go, seems to be the most important method of the simulator, the one actually running the simulation.
 	fmt.Printf("DEBUG FRA, simulate.Simulate(): executing RunCluster().\n")
 	var failedPods []simontype.UnscheduledPod
@@ -149,6 +255,10 @@ func Simulate(cluster ResourceTypes, apps []AppResource, opts ...Option) (*simon
 	utils.ReportFailedPods(failedPods)
 	sim.ClusterAnalysis(TagInitSchedule)
 
+	// Report how much of the scheduled workload ended up on the reclaimed QoS
+	// tier (see plugin.QoSAwareFitPlugin), i.e. co-located onto guaranteed headroom.
+	log.Infof("Reclaimed-tier colocation ratio: %.2f%%\n", 100*plugin.ColocationRatio(cluster.Pods))
+
 
 
 	// export a cluster snapshot after scheduling
@@ -176,6 +286,10 @@ func Simulate(cluster ResourceTypes, apps []AppResource, opts ...Option) (*simon
 			sim.ExportNodeSnapshotInCSV(filePath)
 			podFilePath := fmt.Sprintf("%s/%s", fileDir, "pod-snapshot.csv")
 			sim.ExportPodSnapshotInCSV(podFilePath)
+			if len(cluster.ElasticQuotas) > 0 {
+				quotaFilePath := fmt.Sprintf("%s/%s", fileDir, "elasticquota-snapshot.csv")
+				sim.ExportElasticQuotaSnapshotInCSV(quotaFilePath)
+			}
 		}
 	}
 
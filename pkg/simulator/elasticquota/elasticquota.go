@@ -0,0 +1,258 @@
+package elasticquota
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	resourcehelper "k8s.io/kubectl/pkg/util/resource"
+
+	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/api/v1alpha1"
+)
+
+// EventKind distinguishes the two kinds of events recorded while running the
+// elastic-quota subsystem, so they can be told apart in the CSV export.
+type EventKind string
+
+const (
+	EventBorrow EventKind = "borrow"
+	EventReturn EventKind = "return"
+)
+
+// Event records a single borrow/return of capacity between two namespaces'
+// quotas, for the CSV export produced next to ExportPodSnapshotInCSV.
+type Event struct {
+	Kind          EventKind
+	LenderNS      string
+	BorrowerNS    string
+	Pod           string
+	MilliCpu      int64
+	Memory        int64
+}
+
+// borrowRecord is one admitted pod's draw against a single lender, recorded
+// so Return can unwind exactly what that pod borrowed instead of guessing
+// from the borrower namespace's aggregate.
+type borrowRecord struct {
+	lenderNS string
+	milliCpu int64
+	memory   int64
+}
+
+// quotaState tracks the live admission state of a single namespace's
+// ElasticQuota: how much it currently uses, how much of that usage was
+// borrowed from other namespaces' slack (per admitted pod, so two pods
+// borrowing from two different lenders can be returned independently), and
+// -- when acting as a lender -- how much of its own slack has already been
+// committed to borrowers.
+type quotaState struct {
+	quota        *v1alpha1.ElasticQuota
+	usedMilliCpu int64
+	usedMemory   int64
+	borrowedBy   map[types.UID]borrowRecord // admitted pod UID -> its borrow, for Return bookkeeping
+	lentMilliCpu map[string]int64           // borrower namespace -> milliCPU lent, so it isn't lent out twice
+	lentMemory   map[string]int64           // borrower namespace -> memory lent, so it isn't lent out twice
+}
+
+// Manager admits pods against a set of per-namespace ElasticQuota objects: a
+// namespace's usage below `min` is always admitted, usage between `min` and
+// `max` is only admitted by borrowing slack from namespaces currently below
+// their own min.
+type Manager struct {
+	quotas map[string]*quotaState
+	events []Event
+}
+
+// NewManager builds a Manager from the ElasticQuota specs that flowed in
+// through ResourceTypes.ElasticQuotas.
+func NewManager(quotas []*v1alpha1.ElasticQuota) *Manager {
+	m := &Manager{quotas: make(map[string]*quotaState)}
+	for _, q := range quotas {
+		m.quotas[q.Namespace] = &quotaState{
+			quota:        q,
+			borrowedBy:   make(map[types.UID]borrowRecord),
+			lentMilliCpu: make(map[string]int64),
+			lentMemory:   make(map[string]int64),
+		}
+	}
+	return m
+}
+
+// Admit decides whether pod can be admitted into its namespace's quota. It
+// returns false when the namespace has no declared quota (callers should fall
+// back to the default, unconstrained admission path in that case).
+func (m *Manager) Admit(pod *corev1.Pod) (admitted bool, ok bool) {
+	state, exists := m.quotas[pod.Namespace]
+	if !exists {
+		return false, false
+	}
+
+	req, _ := resourcehelper.PodRequestsAndLimits(pod)
+	milliCpu := req.Cpu().MilliValue()
+	memory := req.Memory().Value()
+
+	newMilliCpu := state.usedMilliCpu + milliCpu
+	newMemory := state.usedMemory + memory
+
+	// Case 1: namespace total stays under its min -- always admitted.
+	if newMilliCpu <= state.quota.Spec.Min.Cpu().MilliValue() && newMemory <= state.quota.Spec.Min.Memory().Value() {
+		state.usedMilliCpu, state.usedMemory = newMilliCpu, newMemory
+		return true, true
+	}
+
+	// Case 2: namespace total stays under its max -- only admitted by
+	// borrowing slack from namespaces that are currently under their min.
+	if newMilliCpu > state.quota.Spec.Max.Cpu().MilliValue() || newMemory > state.quota.Spec.Max.Memory().Value() {
+		return false, true
+	}
+	lenderNS, ok := m.findLenderWithSlack(pod.Namespace, milliCpu, memory)
+	if !ok {
+		log.Debugf("elasticquota: pod %s/%s wants to borrow %dm cpu / %d mem but no namespace has slack\n", pod.Namespace, pod.Name, milliCpu, memory)
+		return false, true
+	}
+
+	state.usedMilliCpu, state.usedMemory = newMilliCpu, newMemory
+	state.borrowedBy[pod.UID] = borrowRecord{lenderNS: lenderNS, milliCpu: milliCpu, memory: memory}
+	m.events = append(m.events, Event{Kind: EventBorrow, LenderNS: lenderNS, BorrowerNS: pod.Namespace, Pod: pod.Name, MilliCpu: milliCpu, Memory: memory})
+	return true, true
+}
+
+// findLenderWithSlack returns a namespace other than borrowerNS whose current
+// usage is below its min by at least (milliCpu, memory), after accounting for
+// slack already committed to other borrowers so the same headroom is never
+// lent out twice.
+func (m *Manager) findLenderWithSlack(borrowerNS string, milliCpu, memory int64) (string, bool) {
+	for ns, state := range m.quotas {
+		if ns == borrowerNS {
+			continue
+		}
+		var committedMilliCpu, committedMemory int64
+		for _, v := range state.lentMilliCpu {
+			committedMilliCpu += v
+		}
+		for _, v := range state.lentMemory {
+			committedMemory += v
+		}
+		slackMilliCpu := state.quota.Spec.Min.Cpu().MilliValue() - state.usedMilliCpu - committedMilliCpu
+		slackMemory := state.quota.Spec.Min.Memory().Value() - state.usedMemory - committedMemory
+		if slackMilliCpu >= milliCpu && slackMemory >= memory {
+			state.lentMilliCpu[borrowerNS] += milliCpu
+			state.lentMemory[borrowerNS] += memory
+			return ns, true
+		}
+	}
+	return "", false
+}
+
+// Return releases a previously-admitted pod's usage, recording a Return event
+// if the pod had borrowed capacity so the CSV export can show the full
+// borrow/return lifecycle. Only the specific lender pod.UID borrowed from
+// (tracked in borrowedBy) is unwound, so returning one pod can't release
+// capacity another pod from the same namespace borrowed from a different
+// lender.
+func (m *Manager) Return(pod *corev1.Pod) {
+	state, exists := m.quotas[pod.Namespace]
+	if !exists {
+		return
+	}
+	req, _ := resourcehelper.PodRequestsAndLimits(pod)
+	milliCpu := req.Cpu().MilliValue()
+	memory := req.Memory().Value()
+
+	state.usedMilliCpu -= milliCpu
+	state.usedMemory -= memory
+
+	record, borrowed := state.borrowedBy[pod.UID]
+	if !borrowed {
+		return
+	}
+	delete(state.borrowedBy, pod.UID)
+	if lender, ok := m.quotas[record.lenderNS]; ok {
+		lender.lentMilliCpu[pod.Namespace] -= record.milliCpu
+		lender.lentMemory[pod.Namespace] -= record.memory
+	}
+	m.events = append(m.events, Event{Kind: EventReturn, LenderNS: record.lenderNS, BorrowerNS: pod.Namespace, Pod: pod.Name, MilliCpu: record.milliCpu, Memory: record.memory})
+}
+
+// Utilization reports, per namespace, the current usage against min/max, for
+// the CSV export.
+type Utilization struct {
+	Namespace    string
+	UsedMilliCpu int64
+	UsedMemory   int64
+	MinMilliCpu  int64
+	MaxMilliCpu  int64
+}
+
+func (m *Manager) Utilizations() []Utilization {
+	out := make([]Utilization, 0, len(m.quotas))
+	for ns, state := range m.quotas {
+		out = append(out, Utilization{
+			Namespace:    ns,
+			UsedMilliCpu: state.usedMilliCpu,
+			UsedMemory:   state.usedMemory,
+			MinMilliCpu:  state.quota.Spec.Min.Cpu().MilliValue(),
+			MaxMilliCpu:  state.quota.Spec.Max.Cpu().MilliValue(),
+		})
+	}
+	return out
+}
+
+func (m *Manager) Events() []Event {
+	return m.events
+}
+
+// ExportCSV writes per-quota utilization followed by the borrow/return event
+// log to filePath, mirroring the layout of ExportPodSnapshotInCSV.
+func ExportCSV(m *Manager, filePath string) error {
+	fmt.Printf("DEBUG FRA, elasticquota.ExportCSV() => exporting elastic quota report to %s\n", filePath)
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create elastic quota csv(%s): %w", filePath, err)
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	defer w.Flush()
+
+	if err := w.Write([]string{"namespace", "used_millicpu", "used_memory", "min_millicpu", "max_millicpu"}); err != nil {
+		return err
+	}
+	for _, u := range m.Utilizations() {
+		row := []string{
+			u.Namespace,
+			strconv.FormatInt(u.UsedMilliCpu, 10),
+			strconv.FormatInt(u.UsedMemory, 10),
+			strconv.FormatInt(u.MinMilliCpu, 10),
+			strconv.FormatInt(u.MaxMilliCpu, 10),
+		}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+
+	if err := w.Write([]string{"kind", "lender_ns", "borrower_ns", "pod", "millicpu", "memory"}); err != nil {
+		return err
+	}
+	for _, e := range m.Events() {
+		row := []string{
+			string(e.Kind),
+			e.LenderNS,
+			e.BorrowerNS,
+			e.Pod,
+			strconv.FormatInt(e.MilliCpu, 10),
+			strconv.FormatInt(e.Memory, 10),
+		}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
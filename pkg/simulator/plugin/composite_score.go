@@ -0,0 +1,94 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/api/v1alpha1"
+	simontype "github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/type"
+	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/utils"
+)
+
+// compositeScoreStrategiesFromConfig reads utils.NodeScoreStrategies' weights
+// and RequestedToCapacityRatio shape out of profile.
+//
+// NOTE: v1alpha1.CustomConfig.SchedulerConfig is expected to carry a
+// NodeScoreStrategies field of this same shape (see
+// utils.NodeScoreStrategies' own doc comment: "Configured via v1alpha1."),
+// populated from the Simon CR by the scheduler config loader. All-zero
+// weights (the field unset, or no override in the CR) fall back to scoring
+// purely on the fragmentation metric, matching NodeGpuShareFragAmountScore's
+// own plugin before CompositeScorePlugin existed.
+func compositeScoreStrategiesFromConfig(profile v1alpha1.CustomConfig) utils.NodeScoreStrategies {
+	cfg := profile.SchedulerConfig.NodeScoreStrategies
+	if cfg.WeightFrag == 0 && cfg.WeightLeastRequested == 0 && cfg.WeightMostRequested == 0 && cfg.WeightRequestedToCapacityRatio == 0 {
+		return utils.NodeScoreStrategies{WeightFrag: 1}
+	}
+	return cfg
+}
+
+// CompositeScorePlugin wires utils.NodeScoreStrategies.CompositeScore into the
+// Score extension point, letting a profile blend the fragmentation metric
+// with the classic least-requested/most-requested/requested-to-capacity-ratio
+// strategies instead of picking exactly one via separate plugins.
+type CompositeScorePlugin struct {
+	handle      framework.Handle
+	strategies  utils.NodeScoreStrategies
+	typicalPods *simontype.TargetPodList
+}
+
+var _ framework.ScorePlugin = &CompositeScorePlugin{}
+
+func NewCompositeScorePlugin(_ runtime.Object, handle framework.Handle, strategies utils.NodeScoreStrategies, typicalPods *simontype.TargetPodList) (framework.Plugin, error) {
+	return &CompositeScorePlugin{handle: handle, strategies: strategies, typicalPods: typicalPods}, nil
+}
+
+func (plugin *CompositeScorePlugin) Name() string {
+	return simontype.CompositeScorePluginName
+}
+
+func (plugin *CompositeScorePlugin) Score(ctx context.Context, state *framework.CycleState, p *v1.Pod, nodeName string) (int64, *framework.Status) {
+	nodeInfo, err := plugin.handle.SnapshotSharedLister().NodeInfos().Get(nodeName)
+	if err != nil || nodeInfo == nil || nodeInfo.Node() == nil {
+		return framework.MinNodeScore, framework.NewStatus(framework.Error, fmt.Sprintf("failed to get nodeInfo(%s)\n", nodeName))
+	}
+
+	nodeResPtr := utils.GetNodeResourceViaHandleAndName(plugin.handle, nodeName)
+	if nodeResPtr == nil {
+		return framework.MinNodeScore, framework.NewStatus(framework.Error, fmt.Sprintf("failed to get nodeRes(%s)\n", nodeName))
+	}
+	nodeRes := *nodeResPtr
+	podRes := utils.GetPodResource(p)
+
+	node := nodeInfo.Node()
+	allocatableMilliCpu := node.Status.Allocatable.Cpu().MilliValue()
+	allocatableMemory := node.Status.Allocatable.Memory().Value()
+	alloc := utils.NodeAllocatable{
+		AllocatableMilliCpu: allocatableMilliCpu,
+		RequestedMilliCpu:   allocatableMilliCpu - nodeRes.MilliCpuLeft,
+		AllocatableMemory:   allocatableMemory,
+		RequestedMemory:     allocatableMemory - nodeRes.MemoryLeft,
+	}
+
+	var typicalPods simontype.TargetPodList
+	if plugin.typicalPods != nil {
+		typicalPods = *plugin.typicalPods
+	}
+
+	score := plugin.strategies.CompositeScore(alloc, nodeRes, podRes, typicalPods)
+	if score > float64(framework.MaxNodeScore) {
+		score = float64(framework.MaxNodeScore)
+	}
+	if score < float64(framework.MinNodeScore) {
+		score = float64(framework.MinNodeScore)
+	}
+	return int64(score), framework.NewStatus(framework.Success)
+}
+
+func (plugin *CompositeScorePlugin) ScoreExtensions() framework.ScoreExtensions {
+	return nil
+}
@@ -0,0 +1,218 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	resourcehelper "k8s.io/kubectl/pkg/util/resource"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/api/v1alpha1"
+	simontype "github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/type"
+)
+
+// ResourceWeights configures how much each resource dimension counts towards
+// BalancedAllocationScorePlugin's variance and MostAllocatedScorePlugin's
+// weighted sum. ExtendedResources is keyed by the same name used in
+// Options.ExtendedResources / the ResourceHandler registry (e.g. "open-local",
+// "gpu"); a resource missing from the map defaults to weight 1.
+type ResourceWeights struct {
+	CPU               float64
+	Memory            float64
+	ExtendedResources map[string]float64
+}
+
+// DefaultResourceWeights weighs CPU, memory, and every extended resource
+// equally, matching the unweighted variance/sum upstream's
+// balanced_allocation.go and most_allocated.go compute over cpu/memory alone.
+var DefaultResourceWeights = ResourceWeights{CPU: 1, Memory: 1}
+
+// resourceWeightsFromConfig derives ResourceWeights from profile's scheduler
+// config.
+//
+// NOTE: v1alpha1.CustomConfig.SchedulerConfig is expected to carry a
+// ResourceWeights field (CPU/Memory float64 plus an ExtendedResources
+// map[string]float64, the same shape as this package's own ResourceWeights),
+// populated from the Simon CR by the scheduler config loader. A zero CPU and
+// Memory weight (the field unset, or no override in the CR) falls back to
+// DefaultResourceWeights.
+func resourceWeightsFromConfig(profile v1alpha1.CustomConfig) ResourceWeights {
+	cfg := profile.SchedulerConfig.ResourceWeights
+	if cfg.CPU == 0 && cfg.Memory == 0 {
+		return DefaultResourceWeights
+	}
+	return ResourceWeights{CPU: cfg.CPU, Memory: cfg.Memory, ExtendedResources: cfg.ExtendedResources}
+}
+
+func (w ResourceWeights) extendedWeight(name string) float64 {
+	if weight, ok := w.ExtendedResources[name]; ok {
+		return weight
+	}
+	return 1
+}
+
+// resourceFractions computes the node's post-scheduling usage fraction for
+// CPU, memory, and every registered extended resource requested via
+// extendedResources, each in [0, 1]. Extended-resource fractions come from
+// 1 - ResourceHandler.Score/100 (see resource_handler.go), the handler's
+// node-wide headroom; they do not include the pod's own request, and Fit's
+// per-VG / per-device checks play no part. CPU/memory add the pod's own request on top
+// of what the node already has bound, matching upstream's "would-be" fraction.
+func resourceFractions(node *v1.Node, pod *v1.Pod, nodeInfo *framework.NodeInfo, extendedResources []string, registry *NodeStateRegistry, vgOvercommitRatio float64) map[string]float64 {
+	fractions := make(map[string]float64, 2+len(extendedResources))
+
+	allocatableMilliCpu := node.Status.Allocatable.Cpu().MilliValue()
+	allocatableMemory := node.Status.Allocatable.Memory().Value()
+
+	var usedMilliCpu, usedMemory int64
+	for _, podInfo := range nodeInfo.Pods {
+		req, _ := resourcehelper.PodRequestsAndLimits(podInfo.Pod)
+		usedMilliCpu += req.Cpu().MilliValue()
+		usedMemory += req.Memory().Value()
+	}
+	podReq, _ := resourcehelper.PodRequestsAndLimits(pod)
+	fractions["cpu"] = clampFraction(usedMilliCpu+podReq.Cpu().MilliValue(), allocatableMilliCpu)
+	fractions["memory"] = clampFraction(usedMemory+podReq.Memory().Value(), allocatableMemory)
+
+	for _, name := range extendedResources {
+		handler, err := NewResourceHandler(name, registry, vgOvercommitRatio)
+		if err != nil {
+			continue
+		}
+		fractions[name] = 1 - float64(handler.Score(node, pod))/100
+	}
+	return fractions
+}
+
+func clampFraction(used, capacity int64) float64 {
+	if capacity <= 0 {
+		return 0
+	}
+	f := float64(used) / float64(capacity)
+	if f > 1 {
+		return 1
+	}
+	if f < 0 {
+		return 0
+	}
+	return f
+}
+
+// BalancedAllocationScorePlugin prefers nodes whose CPU/memory/extended-resource
+// usage fractions are closest to each other, following the variance-based
+// approach of pkg/scheduler/framework/plugins/noderesources/balanced_allocation.go
+// but extended to also weigh registered extended resources (open-local VG,
+// GPU memory/count) instead of just CPU and memory.
+type BalancedAllocationScorePlugin struct {
+	handle            framework.Handle
+	weights           ResourceWeights
+	extendedResources []string
+	registry          *NodeStateRegistry
+	vgOvercommitRatio float64
+}
+
+var _ framework.ScorePlugin = &BalancedAllocationScorePlugin{}
+
+func NewBalancedAllocationScorePlugin(_ runtime.Object, handle framework.Handle, weights ResourceWeights, extendedResources []string, registry *NodeStateRegistry, vgOvercommitRatio float64) (framework.Plugin, error) {
+	return &BalancedAllocationScorePlugin{handle: handle, weights: weights, extendedResources: extendedResources, registry: registry, vgOvercommitRatio: vgOvercommitRatio}, nil
+}
+
+func (plugin *BalancedAllocationScorePlugin) Name() string {
+	return simontype.BalancedAllocationScorePluginName
+}
+
+func (plugin *BalancedAllocationScorePlugin) Score(ctx context.Context, state *framework.CycleState, p *v1.Pod, nodeName string) (int64, *framework.Status) {
+	nodeInfo, err := plugin.handle.SnapshotSharedLister().NodeInfos().Get(nodeName)
+	if err != nil || nodeInfo == nil || nodeInfo.Node() == nil {
+		return framework.MinNodeScore, framework.NewStatus(framework.Error, fmt.Sprintf("failed to get nodeInfo(%s)\n", nodeName))
+	}
+
+	fractions := resourceFractions(nodeInfo.Node(), p, nodeInfo, plugin.extendedResources, plugin.registry, plugin.vgOvercommitRatio)
+	weights := map[string]float64{"cpu": plugin.weights.CPU, "memory": plugin.weights.Memory}
+	for _, name := range plugin.extendedResources {
+		if _, ok := fractions[name]; ok {
+			weights[name] = plugin.weights.extendedWeight(name)
+		}
+	}
+
+	var weightedSum, weightSum float64
+	for name, f := range fractions {
+		weightedSum += f * weights[name]
+		weightSum += weights[name]
+	}
+	if weightSum == 0 {
+		return framework.MaxNodeScore, framework.NewStatus(framework.Success)
+	}
+	mean := weightedSum / weightSum
+
+	var weightedVariance float64
+	for name, f := range fractions {
+		diff := f - mean
+		weightedVariance += weights[name] * diff * diff
+	}
+	weightedVariance /= weightSum
+
+	score := int64((1 - math.Sqrt(weightedVariance)) * float64(framework.MaxNodeScore))
+	return score, framework.NewStatus(framework.Success)
+}
+
+func (plugin *BalancedAllocationScorePlugin) ScoreExtensions() framework.ScoreExtensions {
+	return nil
+}
+
+// MostAllocatedScorePlugin prefers nodes with the highest weighted usage
+// fraction across CPU, memory, and registered extended resources, for
+// bin-packing experiments that want to fill already-busy nodes first instead
+// of spreading pods evenly (the opposite of BalancedAllocationScorePlugin),
+// following pkg/scheduler/framework/plugins/noderesources/most_allocated.go.
+type MostAllocatedScorePlugin struct {
+	handle            framework.Handle
+	weights           ResourceWeights
+	extendedResources []string
+	registry          *NodeStateRegistry
+	vgOvercommitRatio float64
+}
+
+var _ framework.ScorePlugin = &MostAllocatedScorePlugin{}
+
+func NewMostAllocatedScorePlugin(_ runtime.Object, handle framework.Handle, weights ResourceWeights, extendedResources []string, registry *NodeStateRegistry, vgOvercommitRatio float64) (framework.Plugin, error) {
+	return &MostAllocatedScorePlugin{handle: handle, weights: weights, extendedResources: extendedResources, registry: registry, vgOvercommitRatio: vgOvercommitRatio}, nil
+}
+
+func (plugin *MostAllocatedScorePlugin) Name() string {
+	return simontype.MostAllocatedScorePluginName
+}
+
+func (plugin *MostAllocatedScorePlugin) Score(ctx context.Context, state *framework.CycleState, p *v1.Pod, nodeName string) (int64, *framework.Status) {
+	nodeInfo, err := plugin.handle.SnapshotSharedLister().NodeInfos().Get(nodeName)
+	if err != nil || nodeInfo == nil || nodeInfo.Node() == nil {
+		return framework.MinNodeScore, framework.NewStatus(framework.Error, fmt.Sprintf("failed to get nodeInfo(%s)\n", nodeName))
+	}
+
+	fractions := resourceFractions(nodeInfo.Node(), p, nodeInfo, plugin.extendedResources, plugin.registry, plugin.vgOvercommitRatio)
+	weights := map[string]float64{"cpu": plugin.weights.CPU, "memory": plugin.weights.Memory}
+	for _, name := range plugin.extendedResources {
+		if _, ok := fractions[name]; ok {
+			weights[name] = plugin.weights.extendedWeight(name)
+		}
+	}
+
+	var weightedSum, weightSum float64
+	for name, f := range fractions {
+		weightedSum += f * weights[name]
+		weightSum += weights[name]
+	}
+	if weightSum == 0 {
+		return framework.MinNodeScore, framework.NewStatus(framework.Success)
+	}
+
+	score := int64(weightedSum / weightSum * float64(framework.MaxNodeScore))
+	return score, framework.NewStatus(framework.Success)
+}
+
+func (plugin *MostAllocatedScorePlugin) ScoreExtensions() framework.ScoreExtensions {
+	return nil
+}
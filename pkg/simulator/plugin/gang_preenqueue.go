@@ -0,0 +1,69 @@
+package plugin
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	simontype "github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/type"
+)
+
+// AnnoPodGroupMinMember is the pod annotation naming how many pods of a gang
+// (a PodGroup) must be schedulable together before any of them is allowed
+// into the active queue.
+const AnnoPodGroupMinMember = "simon/pod-group-min-member"
+
+// GangPreEnqueuePlugin holds gang-scheduled pods out of the active queue
+// until enough siblings of the same PodGroup have arrived, matching how
+// modern kube-scheduler uses PreEnqueue for coscheduling plugins such as
+// kube-batch/Volcano's PodGroup.
+type GangPreEnqueuePlugin struct {
+	// arrived tracks, per PodGroup name, the set of distinct pod UIDs seen by
+	// PreEnqueue so far, so repeated evaluations of the same pod (retries/
+	// requeues) don't inflate the member count.
+	arrived map[string]map[types.UID]struct{}
+}
+
+var _ PreEnqueuePlugin = &GangPreEnqueuePlugin{}
+
+func NewGangPreEnqueuePlugin() (framework.Plugin, error) {
+	return &GangPreEnqueuePlugin{arrived: make(map[string]map[types.UID]struct{})}, nil
+}
+
+func (plugin *GangPreEnqueuePlugin) Name() string {
+	return simontype.GangPreEnqueuePluginName
+}
+
+// PreEnqueue admits a pod into the active queue only once its PodGroup has
+// reached minMember; pods without a PodGroup annotation are admitted
+// immediately.
+func (plugin *GangPreEnqueuePlugin) PreEnqueue(p *framework.QueuedPodInfo) *framework.Status {
+	pod := p.Pod
+	groupName, hasGroup := pod.Labels[simontype.LabelPodGroupName]
+	if !hasGroup {
+		return framework.NewStatus(framework.Success)
+	}
+
+	minMemberStr, ok := pod.Annotations[AnnoPodGroupMinMember]
+	if !ok {
+		return framework.NewStatus(framework.Success)
+	}
+
+	members, ok := plugin.arrived[groupName]
+	if !ok {
+		members = make(map[types.UID]struct{})
+		plugin.arrived[groupName] = members
+	}
+	members[pod.UID] = struct{}{}
+
+	var minMember int
+	if _, err := fmt.Sscanf(minMemberStr, "%d", &minMember); err != nil {
+		return framework.NewStatus(framework.Error, fmt.Sprintf("invalid %s on pod %s: %v", AnnoPodGroupMinMember, pod.Name, err))
+	}
+
+	if len(members) < minMember {
+		return framework.NewStatus(framework.UnschedulableAndUnresolvable, fmt.Sprintf("podGroup %s waiting for more members (%d/%d)", groupName, len(members), minMember))
+	}
+	return framework.NewStatus(framework.Success)
+}
@@ -0,0 +1,213 @@
+package plugin
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+
+	simontype "github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/type"
+	gpushareutils "github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/type/open-gpu-share/utils"
+	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/utils"
+)
+
+// sortedDeviceIDs returns devices' keys in sorted order, not map order
+// (which Go randomizes per process): allocateMIG/allocateTopologyAware pick
+// a device based on iteration order (first match, or first in a
+// tie-breaking combo search), so an unsorted range would make which device a
+// pod lands on non-reproducible across runs sharing the same seed.
+func sortedDeviceIDs(devices map[string]*DeviceInfo) []string {
+	ids := make([]string, 0, len(devices))
+	for id := range devices {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// GPUMode is the allocation strategy GPUAllocator picks for a pod, based on
+// what it requests.
+type GPUMode string
+
+const (
+	// GPUModeExclusive hands a pod one or more whole devices.
+	GPUModeExclusive GPUMode = "exclusive"
+	// GPUModeFractional shares a single device's memory/compute percentage
+	// across pods, the model PredicateGPU/AllocateGPU already implement.
+	GPUModeFractional GPUMode = "fractional"
+	// GPUModeMIG carves a pod's requested MigProfile out of a device already
+	// in MIG mode (DeviceInfo.MigTree != nil).
+	GPUModeMIG GPUMode = "mig"
+)
+
+// GPUAllocator is the single entry point for GPU Fit/allocate decisions,
+// dispatching to whichever of the three modes a pod's request implies, so
+// downstream occupancy/fragmentation metrics can report per-device
+// utilization (DeviceInfo.AllocatedMilli / MigTree.FreeGPCs) instead of just
+// a node-level GPU-milli total.
+//
+// NOTE: devices is still passed in explicitly rather than looked up from the
+// node, so callers choosing to track their own devices map (tests, one-off
+// scripts) remain free to; callers that want allocations to persist across
+// scheduling cycles should source it from GPUDevicesForNode instead, which
+// caches it on the node's NodeState (see node_state.go).
+type GPUAllocator struct {
+	devices map[string]*DeviceInfo
+	topo    *NodeGpuTopology
+}
+
+func NewGPUAllocator(devices map[string]*DeviceInfo, topo *NodeGpuTopology) *GPUAllocator {
+	return &GPUAllocator{devices: devices, topo: topo}
+}
+
+// ModeFor returns which GPUMode podRes would be allocated under.
+func ModeFor(podRes simontype.PodResource) GPUMode {
+	if podRes.MigProfile != "" {
+		return GPUModeMIG
+	}
+	if podRes.GpuNumber >= 1 && podRes.MilliGpu == gpushareutils.MILLI {
+		return GPUModeExclusive
+	}
+	return GPUModeFractional
+}
+
+// Fit reports whether the allocator can satisfy podRes without mutating any
+// device state, mirroring PredicateGPU for the exclusive/fractional modes and
+// adding the MIG case.
+func (a *GPUAllocator) Fit(podRes simontype.PodResource) error {
+	switch ModeFor(podRes) {
+	case GPUModeMIG:
+		for _, id := range sortedDeviceIDs(a.devices) {
+			d := a.devices[id]
+			if d.MigTree != nil && d.MigTree.CanAllocateMigProfile(utils.MigProfile(podRes.MigProfile)) {
+				return nil
+			}
+		}
+		return fmt.Errorf("no device can carve out mig profile %s", podRes.MigProfile)
+	default:
+		return PredicateGPU(podRes, a.devices)
+	}
+}
+
+// Allocate picks device(s) for pod per podRes, records the assignment against
+// the chosen DeviceInfo(s) (and, for multi-GPU exclusive/fractional pods,
+// prefers the device set with the highest pairwise topology bandwidth via
+// a.topo), and patches AnnoGpuIndex the same way AllocateGPU does.
+func (a *GPUAllocator) Allocate(pod *v1.Pod, podRes simontype.PodResource) (deviceIDs string, err error) {
+	switch ModeFor(podRes) {
+	case GPUModeMIG:
+		return a.allocateMIG(pod, podRes)
+	default:
+		if podRes.GpuNumber > 1 && a.topo != nil {
+			return a.allocateTopologyAware(pod, podRes)
+		}
+		return AllocateGPU(pod, podRes, a.devices)
+	}
+}
+
+func (a *GPUAllocator) allocateMIG(pod *v1.Pod, podRes simontype.PodResource) (string, error) {
+	for _, id := range sortedDeviceIDs(a.devices) {
+		d := a.devices[id]
+		if d.MigTree == nil || !d.MigTree.CanAllocateMigProfile(utils.MigProfile(podRes.MigProfile)) {
+			continue
+		}
+		d.MigTree.FreeGPCs -= migGPCCostOf(utils.MigProfile(podRes.MigProfile))
+		d.SharingPods = append(d.SharingPods, PodRef{Namespace: pod.Namespace, Name: pod.Name, MilliGpu: 0})
+		if pod.Annotations == nil {
+			pod.Annotations = make(map[string]string)
+		}
+		pod.Annotations[AnnoGpuIndex] = id
+		return id, nil
+	}
+	return "", fmt.Errorf("no device can carve out mig profile %s", podRes.MigProfile)
+}
+
+// allocateTopologyAware picks, among every combination of candidate devices
+// with enough free capacity, the one maximizing MinPairwiseBandwidth, then
+// records the assignment exactly like AllocateGPU.
+func (a *GPUAllocator) allocateTopologyAware(pod *v1.Pod, podRes simontype.PodResource) (string, error) {
+	if err := PredicateGPU(podRes, a.devices); err != nil {
+		return "", err
+	}
+
+	var candidates []string
+	for _, id := range sortedDeviceIDs(a.devices) {
+		if a.devices[id].FreeMilliGpu() >= podRes.MilliGpu {
+			candidates = append(candidates, id)
+		}
+	}
+
+	best := []string{candidates[0]}
+	bestBW := -1.0
+	var combo func(start int, chosen []string)
+	combo = func(start int, chosen []string) {
+		if int64(len(chosen)) == podRes.GpuNumber {
+			if bw := a.topo.MinPairwiseBandwidth(chosen); bw > bestBW {
+				bestBW = bw
+				best = append([]string(nil), chosen...)
+			}
+			return
+		}
+		for i := start; i < len(candidates); i++ {
+			combo(i+1, append(chosen, candidates[i]))
+		}
+	}
+	combo(0, nil)
+
+	for _, id := range best {
+		d := a.devices[id]
+		d.AllocatedMilli += podRes.MilliGpu
+		d.SharingPods = append(d.SharingPods, PodRef{Namespace: pod.Namespace, Name: pod.Name, MilliGpu: podRes.MilliGpu})
+	}
+
+	deviceID := best[0]
+	for _, id := range best[1:] {
+		deviceID += "," + id
+	}
+	if pod.Annotations == nil {
+		pod.Annotations = make(map[string]string)
+	}
+	pod.Annotations[AnnoGpuIndex] = deviceID
+	return deviceID, nil
+}
+
+// DeviceUtilization reports, per device ID, the fraction of its capacity
+// currently allocated -- MilliGpu-based for exclusive/fractional devices, GPC
+// based for devices in MIG mode -- so callers can report per-device
+// occupancy instead of a single node-level ratio.
+func (a *GPUAllocator) DeviceUtilization() map[string]float64 {
+	out := make(map[string]float64, len(a.devices))
+	for id, d := range a.devices {
+		if d.MigTree != nil {
+			out[id] = 1 - float64(d.MigTree.FreeGPCs)/7
+			continue
+		}
+		if d.TotalMilliGpu == 0 {
+			out[id] = 0
+			continue
+		}
+		out[id] = float64(d.AllocatedMilli) / float64(d.TotalMilliGpu)
+	}
+	return out
+}
+
+// migGPCCostOf mirrors utils' unexported migGPCCost table; duplicated here
+// (rather than exported from pkg/utils) since it's GPUAllocator's only
+// consumer of the raw GPC cost, everything else goes through
+// MigPartitionTree.CanAllocateMigProfile.
+func migGPCCostOf(profile utils.MigProfile) int {
+	switch profile {
+	case utils.Mig1g5gb:
+		return 1
+	case utils.Mig2g10gb:
+		return 2
+	case utils.Mig3g20gb:
+		return 3
+	case utils.Mig4g20gb:
+		return 4
+	case utils.Mig7g40gb:
+		return 7
+	default:
+		return 0
+	}
+}
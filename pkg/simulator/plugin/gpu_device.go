@@ -0,0 +1,161 @@
+package plugin
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+
+	simontype "github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/type"
+	gpushareutils "github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/type/open-gpu-share/utils"
+	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/utils"
+)
+
+// AnnoGpuIndex is the pod annotation patched by AllocateGPU, naming the
+// device(s) a pod was assigned, e.g. "0" or "0,1" for a multi-GPU pod.
+const AnnoGpuIndex = "simon/gpu-index"
+
+// DeviceInfo is a first-class GPU device on a node, replacing the previous
+// MilliGpuLeftList/allocateGpuIdFunc scheme (see pwr_score.go) so that a node
+// can expose several partially-shared GPUs, and so future device types
+// (RDMA NICs, TPUs) can implement the same shape without reusing GPU-specific
+// fields.
+type DeviceInfo struct {
+	UUID           string
+	TotalMilliGpu  int64
+	AllocatedMilli int64
+	SharingPods    []PodRef // pods currently holding a slice of this device
+	// MigTree is non-nil for a device that has been put into MIG mode,
+	// tracking the GPC budget GPUAllocator carves MIG profiles out of (see
+	// gpu_allocator.go). A device not in MIG mode leaves this nil.
+	MigTree *utils.MigPartitionTree
+}
+
+// PodRef identifies a pod sharing a device, without holding a full *v1.Pod
+// (which would keep the device map alive longer than the pod itself).
+type PodRef struct {
+	Namespace string
+	Name      string
+	MilliGpu  int64
+}
+
+func (d *DeviceInfo) FreeMilliGpu() int64 {
+	return d.TotalMilliGpu - d.AllocatedMilli
+}
+
+// PredicateGPU checks whether node has a device (or, for multi-GPU pods, a
+// combination of devices) able to satisfy podRes, without mutating any state.
+func PredicateGPU(podRes simontype.PodResource, devices map[string]*DeviceInfo) error {
+	if podRes.GpuNumber == 0 {
+		return nil
+	}
+
+	fit := 0
+	for _, d := range devices {
+		if d.FreeMilliGpu() >= podRes.MilliGpu {
+			fit++
+		}
+	}
+	if int64(fit) < podRes.GpuNumber {
+		return fmt.Errorf("node has %d device(s) with %dm free, needs %d for pod requesting %dm each", fit, podRes.MilliGpu, podRes.GpuNumber, podRes.MilliGpu)
+	}
+	return nil
+}
+
+// AllocateGPU picks podRes.GpuNumber devices with enough free capacity,
+// atomically records the pod against each chosen device's SharingPods list,
+// and returns the comma-joined device IDs to be patched into AnnoGpuIndex.
+func AllocateGPU(pod *v1.Pod, podRes simontype.PodResource, devices map[string]*DeviceInfo) (deviceID string, err error) {
+	if podRes.GpuNumber == 0 {
+		return "", fmt.Errorf("pod %s/%s requests no GPU, nothing to allocate", pod.Namespace, pod.Name)
+	}
+	if err := PredicateGPU(podRes, devices); err != nil {
+		return "", err
+	}
+
+	// Iterate device IDs in sorted order, not map order (which Go randomizes
+	// per process): otherwise which device(s) a pod lands on isn't
+	// reproducible across runs sharing the same seed, the same concern
+	// RegisteredResourceHandlerNames already guards against for extended
+	// resource names.
+	ids := make([]string, 0, len(devices))
+	for id := range devices {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var chosen []string
+	for _, id := range ids {
+		d := devices[id]
+		if int64(len(chosen)) >= podRes.GpuNumber {
+			break
+		}
+		if d.FreeMilliGpu() < podRes.MilliGpu {
+			continue
+		}
+		d.AllocatedMilli += podRes.MilliGpu
+		d.SharingPods = append(d.SharingPods, PodRef{Namespace: pod.Namespace, Name: pod.Name, MilliGpu: podRes.MilliGpu})
+		chosen = append(chosen, id)
+	}
+
+	deviceID = chosen[0]
+	for _, id := range chosen[1:] {
+		deviceID += "," + id
+	}
+
+	if pod.Annotations == nil {
+		pod.Annotations = make(map[string]string)
+	}
+	pod.Annotations[AnnoGpuIndex] = deviceID
+	return deviceID, nil
+}
+
+// ReleaseGPU undoes AllocateGPU, removing pod's share from every device it
+// was recorded against. Used when a pod is evicted or descheduled.
+func ReleaseGPU(pod *v1.Pod, devices map[string]*DeviceInfo) {
+	for _, d := range devices {
+		for i := 0; i < len(d.SharingPods); i++ {
+			ref := d.SharingPods[i]
+			if ref.Namespace == pod.Namespace && ref.Name == pod.Name {
+				d.AllocatedMilli -= ref.MilliGpu
+				d.SharingPods = append(d.SharingPods[:i], d.SharingPods[i+1:]...)
+				i--
+			}
+		}
+	}
+}
+
+// DevicesFromMilliGpuLeftList builds the new Devices map from the legacy
+// MilliGpuLeftList representation, for nodes that have not yet been migrated
+// to report DeviceInfo directly. total is assumed to be gpushareutils.MILLI
+// per device, matching the existing single-GPU-share model.
+func DevicesFromMilliGpuLeftList(nodeName string, milliGpuLeftList []int64) map[string]*DeviceInfo {
+	devices := make(map[string]*DeviceInfo, len(milliGpuLeftList))
+	for i, left := range milliGpuLeftList {
+		uuid := fmt.Sprintf("%s-gpu-%d", nodeName, i)
+		devices[uuid] = &DeviceInfo{
+			UUID:           uuid,
+			TotalMilliGpu:  gpushareutils.MILLI,
+			AllocatedMilli: gpushareutils.MILLI - left,
+		}
+	}
+	return devices
+}
+
+// nodeStateKeyGPUDevices is this package's Others key (see node_state.go) for
+// a node's cached DeviceInfo map.
+const nodeStateKeyGPUDevices = "gpu-devices"
+
+// GPUDevicesForNode returns nodeName's persistent DeviceInfo map, building it
+// once from milliGpuLeftList via DevicesFromMilliGpuLeftList and caching it in
+// that node's NodeState (within registry, the caller's run-scoped
+// NodeStateRegistry). This lets AllocateGPU/ReleaseGPU mutations survive into
+// later scheduling cycles instead of every caller rebuilding a fresh map from
+// milliGpuLeftList and losing whatever a previous cycle had allocated.
+func GPUDevicesForNode(registry *NodeStateRegistry, nodeName string, milliGpuLeftList []int64) map[string]*DeviceInfo {
+	state := registry.GetOrCreate(nodeName)
+	devices := state.GetOrSet(nodeStateKeyGPUDevices, func() interface{} {
+		return DevicesFromMilliGpuLeftList(nodeName, milliGpuLeftList)
+	})
+	return devices.(map[string]*DeviceInfo)
+}
@@ -0,0 +1,183 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	simontype "github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/type"
+	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/utils"
+)
+
+// AnnoNodeGpuTopology is the node annotation carrying a JSON-encoded
+// NodeGpuTopology, mirroring the AnnoNodeLocalStorage convention used for
+// open-local's VG/device info.
+//
+// NOTE: for synthetic clusters, v1alpha1.CustomConfig is expected to carry a
+// GpuTopologies []v1alpha1.NodeGpuTopologyConfig field (node name -> the same
+// Links shape as NodeGpuTopology below), which CreateClusterResourceFromClusterConfig
+// marshals into this annotation when building each synthetic node.
+const AnnoNodeGpuTopology = "simon/gpu-topology"
+
+// LinkType is the interconnect between a pair of GPU devices on the same
+// node, following nvidia-smi topo's own NVLink/PCIe/SYS classification.
+type LinkType string
+
+const (
+	LinkNVLink LinkType = "NVLink"
+	LinkPCIe   LinkType = "PCIe"
+	LinkSYS    LinkType = "SYS"
+)
+
+// linkBandwidthGBps is the assumed point-to-point bandwidth of each link
+// type, used only to rank device combinations relative to one another -- the
+// simulator does not model actual data transfer.
+var linkBandwidthGBps = map[LinkType]float64{
+	LinkNVLink: 300,
+	LinkPCIe:   16,
+	LinkSYS:    8,
+}
+
+// GpuLink is one entry of NodeGpuTopology.Links: the link to PeerIndex and
+// its type.
+type GpuLink struct {
+	PeerIndex string
+	Link      LinkType
+}
+
+// NodeGpuTopology is the pairwise device link matrix for a node, keyed by
+// GPU index (the same string form AnnoGpuIndex and DevsBrief use), parsed
+// from AnnoNodeGpuTopology. Devices with no entry for a pair are assumed to
+// be connected over LinkSYS (the slowest, most conservative default).
+type NodeGpuTopology struct {
+	Links map[string][]GpuLink `json:"links"`
+}
+
+// GetNodeGpuTopologyFromAnnotation parses node's AnnoNodeGpuTopology
+// annotation, returning (nil, nil) when the node doesn't carry one (e.g. a
+// single-GPU node, for which topology is meaningless).
+func GetNodeGpuTopologyFromAnnotation(node *v1.Node) (*NodeGpuTopology, error) {
+	str, exist := node.Annotations[AnnoNodeGpuTopology]
+	if !exist {
+		return nil, nil
+	}
+	var topo NodeGpuTopology
+	if err := json.Unmarshal([]byte(str), &topo); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal gpu topology of node(%s): %v", node.Name, err)
+	}
+	return &topo, nil
+}
+
+// bandwidthBetween returns the assumed bandwidth between two GPU indices,
+// defaulting to LinkSYS when the pair has no explicit entry.
+func (t *NodeGpuTopology) bandwidthBetween(a, b string) float64 {
+	for _, l := range t.Links[a] {
+		if l.PeerIndex == b {
+			return linkBandwidthGBps[l.Link]
+		}
+	}
+	return linkBandwidthGBps[LinkSYS]
+}
+
+// MinPairwiseBandwidth returns the minimum bandwidth over every pair of
+// indices, i.e. the bottleneck link of the set -- a single index has no
+// inter-device link to bottleneck on, so it returns the NVLink bandwidth.
+func (t *NodeGpuTopology) MinPairwiseBandwidth(indices []string) float64 {
+	if t == nil || len(indices) < 2 {
+		return linkBandwidthGBps[LinkNVLink]
+	}
+	min := linkBandwidthGBps[LinkNVLink]
+	for i := 0; i < len(indices); i++ {
+		for j := i + 1; j < len(indices); j++ {
+			if bw := t.bandwidthBetween(indices[i], indices[j]); bw < min {
+				min = bw
+			}
+		}
+	}
+	return min
+}
+
+// GpuTopologyScorePlugin scores a node, for multi-GPU pods, by how well its
+// best-available device combination's bottleneck link compares to the
+// node's fastest possible interconnect (all-NVLink): nodes that can offer
+// the pod an all-NVLink group score highest. A group is scored by its
+// slowest link only, so a mixed group scores the same as a group built
+// entirely of its slowest link type (NVLink+PCIe ties with PCIe-only).
+type GpuTopologyScorePlugin struct {
+	handle framework.Handle
+}
+
+var _ framework.ScorePlugin = &GpuTopologyScorePlugin{}
+
+func NewGpuTopologyScorePlugin(_ runtime.Object, handle framework.Handle) (framework.Plugin, error) {
+	return &GpuTopologyScorePlugin{handle: handle}, nil
+}
+
+func (plugin *GpuTopologyScorePlugin) Name() string {
+	return simontype.GpuTopologyScorePluginName
+}
+
+func (plugin *GpuTopologyScorePlugin) Score(ctx context.Context, state *framework.CycleState, p *v1.Pod, nodeName string) (int64, *framework.Status) {
+	podRes := utils.GetPodResource(p)
+	if podRes.GpuNumber < 2 {
+		// A single-GPU (or no-GPU) pod has nothing to bottleneck on; defer to
+		// the other score plugins entirely.
+		return framework.MaxNodeScore, framework.NewStatus(framework.Success)
+	}
+
+	nodeInfo, err := plugin.handle.SnapshotSharedLister().NodeInfos().Get(nodeName)
+	if err != nil {
+		return framework.MinNodeScore, framework.NewStatus(framework.Error, fmt.Sprintf("failed to get nodeInfo(%s): %v", nodeName, err))
+	}
+	topo, err := GetNodeGpuTopologyFromAnnotation(nodeInfo.Node())
+	if err != nil {
+		return framework.MinNodeScore, framework.NewStatus(framework.Error, err.Error())
+	}
+
+	nodeResPtr := utils.GetNodeResourceViaHandleAndName(plugin.handle, nodeName)
+	if nodeResPtr == nil {
+		return framework.MinNodeScore, framework.NewStatus(framework.Error, fmt.Sprintf("failed to get nodeRes(%s)\n", nodeName))
+	}
+
+	bestBandwidth := bestAvailableBandwidth(*nodeResPtr, podRes, topo)
+	score := int64(bestBandwidth / linkBandwidthGBps[LinkNVLink] * float64(framework.MaxNodeScore))
+	return score, framework.NewStatus(framework.Success)
+}
+
+func (plugin *GpuTopologyScorePlugin) ScoreExtensions() framework.ScoreExtensions {
+	return nil
+}
+
+// bestAvailableBandwidth searches every combination of podRes.GpuNumber
+// devices with enough free capacity and returns the best (highest)
+// MinPairwiseBandwidth among them. The search is brute-force, which is fine
+// since nodes carry at most a handful of GPUs (8 is the largest GpuNumType in
+// this simulator).
+func bestAvailableBandwidth(nodeRes simontype.NodeResource, podRes simontype.PodResource, topo *NodeGpuTopology) float64 {
+	var candidates []string
+	for i, left := range nodeRes.MilliGpuLeftList {
+		if left >= podRes.MilliGpu {
+			candidates = append(candidates, fmt.Sprintf("%d", i))
+		}
+	}
+
+	best := 0.0
+	var combo func(start int, chosen []string)
+	combo = func(start int, chosen []string) {
+		if int64(len(chosen)) == podRes.GpuNumber {
+			if bw := topo.MinPairwiseBandwidth(chosen); bw > best {
+				best = bw
+			}
+			return
+		}
+		for i := start; i < len(candidates); i++ {
+			combo(i+1, append(chosen, candidates[i]))
+		}
+	}
+	combo(0, nil)
+	return best
+}
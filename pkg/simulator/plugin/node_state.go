@@ -0,0 +1,146 @@
+package plugin
+
+import "sync"
+
+// NodeState is per-node, plugin-owned state that needs to survive across
+// scheduling cycles -- cached GPU device maps, parsed VG free-byte tables,
+// NUMA/interconnect topology graphs -- without every plugin that wants one
+// adding its own field to core.NodeInfo/PodInfo. Modelled on Volcano's
+// pkg/scheduler/api/node_info.go switch from a single `Other interface{}` to
+// `Others map[string]interface{}`: each plugin claims its own key (its plugin
+// name is a natural choice) instead of fighting over one slot.
+//
+// Others is guarded by mu rather than left for callers to synchronize
+// themselves: a *NodeState is reached through a NodeStateRegistry (below),
+// and every plugin and ResourceHandler built against that registry gets the
+// same NodeState for a node name, so concurrent callers within one run can
+// read and write the same Others map at the same time.
+type NodeState struct {
+	mu     sync.Mutex
+	others map[string]interface{}
+}
+
+// Get returns the value stored under key, if any.
+func (s *NodeState) Get(key string) (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.others[key]
+	return v, ok
+}
+
+// Set stores value under key.
+func (s *NodeState) Set(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.others[key] = value
+}
+
+// GetOrSet returns the value already stored under key, or calls build and
+// stores/returns its result if key isn't set yet. The check and the store
+// happen under the same lock, so two callers racing to populate the same key
+// can't clobber each other's value the way a separate Get-then-Set would.
+func (s *NodeState) GetOrSet(key string, build func() interface{}) interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v, ok := s.others[key]; ok {
+		return v
+	}
+	v := build()
+	s.others[key] = v
+	return v
+}
+
+// Clone copies the map itself; it does not deep-copy the values inside it.
+// A value that is a pointer or a map is therefore shared between the clone
+// and the original: the DeviceInfo map GPUDevicesForNode caches, whose
+// entries AllocateGPU/ReleaseGPU update in place, shows the same allocations
+// through both. A clone does get an independent key space: adding or
+// removing entries in it leaves the original unchanged.
+func (s *NodeState) Clone() *NodeState {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := &NodeState{others: make(map[string]interface{}, len(s.others))}
+	for k, v := range s.others {
+		out.others[k] = v
+	}
+	return out
+}
+
+// PodState is NodeState's pod-scoped equivalent: plugin-owned scratch space
+// keyed by plugin name, e.g. a cached QoS classification or parsed resource
+// request a plugin would otherwise re-derive from *v1.Pod on every call.
+type PodState struct {
+	mu     sync.Mutex
+	others map[string]interface{}
+}
+
+// Get returns the value stored under key, if any.
+func (s *PodState) Get(key string) (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.others[key]
+	return v, ok
+}
+
+// Set stores value under key.
+func (s *PodState) Set(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.others[key] = value
+}
+
+// Clone follows the same shallow-map-copy contract as NodeState.Clone.
+func (s *PodState) Clone() *PodState {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := &PodState{others: make(map[string]interface{}, len(s.others))}
+	for k, v := range s.others {
+		out.others[k] = v
+	}
+	return out
+}
+
+// NodeStateRegistry maps a node name to its NodeState, centralizing the
+// node-name-keyed maps plugins already kept privately (e.g.
+// QoSAwareFitPlugin.pools in qos_aware_fit.go) into one place new
+// ResourceHandlers/GPU/QoS subsystems can share instead of each growing their
+// own.
+//
+// A registry is scoped to a single simulator.Simulate call rather than
+// shared process-wide: simulator.New builds one NodeStateRegistry per run
+// (simulator.WithNodeStateRegistry) and threads it down to whichever plugins
+// need it (see registry.go's NewRegistryFromConfig). Two concurrent Simulate
+// runs against clusters that reuse the same node names -- e.g.
+// pkg/apply/sweep.go's worker pool -- therefore get independent NodeStates
+// instead of racing to populate (and corrupt) a shared one, and don't need to
+// serialize their Simulate calls against each other the way a process-wide
+// registry would have required.
+type NodeStateRegistry struct {
+	mu     sync.Mutex
+	states map[string]*NodeState
+}
+
+// NewNodeStateRegistry returns an empty registry, ready for one
+// simulator.Simulate run.
+func NewNodeStateRegistry() *NodeStateRegistry {
+	return &NodeStateRegistry{states: make(map[string]*NodeState)}
+}
+
+// GetOrCreate returns nodeName's NodeState within this registry, creating an
+// empty one on first use.
+func (r *NodeStateRegistry) GetOrCreate(nodeName string) *NodeState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state, ok := r.states[nodeName]
+	if !ok {
+		state = &NodeState{others: make(map[string]interface{})}
+		r.states[nodeName] = state
+	}
+	return state
+}
@@ -0,0 +1,404 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	resourcehelper "k8s.io/kubectl/pkg/util/resource"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/api/v1alpha1"
+	simontype "github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/type"
+	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/utils"
+)
+
+// AnnoCpuset is the pod annotation the plugin writes once it has picked a
+// cpuset for a Guaranteed pod on a specific NUMA socket.
+const AnnoCpuset = "simon/cpuset"
+
+// AnnoNodeNUMATopology is the node annotation carrying a JSON-encoded
+// NodeNUMATopology, mirroring the AnnoNodeGpuTopology convention used for GPU
+// interconnect info.
+const AnnoNodeNUMATopology = "simon/numa-topology"
+
+// numaCPU is a single logical CPU of a NUMA socket. CoreID groups hyperthread
+// siblings together: two numaCPUs on the same socket sharing a CoreID are the
+// same physical core.
+type numaCPU struct {
+	ID     int `json:"id"`
+	CoreID int `json:"coreId"`
+}
+
+// NUMASocketTopology is the static CPU/memory layout of a single socket.
+type NUMASocketTopology struct {
+	Socket      int       `json:"socket"`
+	CPUs        []numaCPU `json:"cpus"`
+	MemoryBytes int64     `json:"memoryBytes"`
+}
+
+// NodeNUMATopology is the per-node NUMA layout parsed from
+// AnnoNodeNUMATopology.
+type NodeNUMATopology struct {
+	Sockets []NUMASocketTopology `json:"sockets"`
+}
+
+// GetNodeNUMATopologyFromAnnotation parses node's AnnoNodeNUMATopology
+// annotation, returning (nil, nil) when the node doesn't carry one (e.g. a
+// single-socket node, for which NUMA placement is meaningless).
+func GetNodeNUMATopologyFromAnnotation(node *v1.Node) (*NodeNUMATopology, error) {
+	str, exist := node.Annotations[AnnoNodeNUMATopology]
+	if !exist {
+		return nil, nil
+	}
+	var topo NodeNUMATopology
+	if err := json.Unmarshal([]byte(str), &topo); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal NUMA topology of node(%s): %v", node.Name, err)
+	}
+	return &topo, nil
+}
+
+// NUMATopologyPlugin assigns Guaranteed pods to a single NUMA socket using a
+// CPU accumulator: it picks the socket with the smallest sufficient free CPU
+// set, preferring full physical cores over lone hyperthread siblings, and
+// records the resulting cpuset on the pod. A pod too large for any single
+// socket falls back to spanning sockets (see spanSockets) instead of being
+// rejected outright.
+type NUMATopologyPlugin struct {
+	handle       framework.Handle
+	reservations map[string][]*v1alpha1.Reservation // nodeName -> reservations pre-claiming cpuset
+}
+
+var _ framework.ReservePlugin = &NUMATopologyPlugin{}
+
+func NewNUMATopologyPlugin(_ runtime.Object, handle framework.Handle, reservations []*v1alpha1.Reservation) (framework.Plugin, error) {
+	plugin := &NUMATopologyPlugin{
+		handle:       handle,
+		reservations: make(map[string][]*v1alpha1.Reservation),
+	}
+	for _, r := range reservations {
+		plugin.reservations[r.NodeName] = append(plugin.reservations[r.NodeName], r)
+	}
+	return plugin, nil
+}
+
+func (plugin *NUMATopologyPlugin) Name() string {
+	return simontype.NUMATopologyPluginName
+}
+
+// Reserve picks a NUMA socket for Guaranteed pods only; Burstable/BestEffort
+// pods are left to float across sockets as usual.
+func (plugin *NUMATopologyPlugin) Reserve(ctx context.Context, state *framework.CycleState, p *v1.Pod, nodeName string) *framework.Status {
+	if p.Status.QOSClass != v1.PodQOSGuaranteed {
+		return framework.NewStatus(framework.Success)
+	}
+
+	nodeInfo, err := plugin.handle.SnapshotSharedLister().NodeInfos().Get(nodeName)
+	if err != nil || nodeInfo == nil {
+		return framework.NewStatus(framework.Error, fmt.Sprintf("failed to get nodeInfo(%s)\n", nodeName))
+	}
+
+	requestedMilliCpu := requestedMilliCpuOf(p)
+	cpuset, socket, err := plugin.pickSocket(nodeName, nodeInfo, requestedMilliCpu, p)
+	if err != nil {
+		return framework.NewStatus(framework.Unschedulable, err.Error())
+	}
+
+	if p.Annotations == nil {
+		p.Annotations = make(map[string]string)
+	}
+	p.Annotations[AnnoCpuset] = cpuset
+	if socket >= 0 {
+		fmt.Printf("DEBUG FRA, plugin.numa_topology.Reserve() => pod %s pinned to socket %d, cpuset %s\n", p.Name, socket, cpuset)
+	} else {
+		fmt.Printf("DEBUG FRA, plugin.numa_topology.Reserve() => pod %s spans sockets, cpuset %s\n", p.Name, cpuset)
+	}
+	return framework.NewStatus(framework.Success)
+}
+
+func (plugin *NUMATopologyPlugin) Unreserve(ctx context.Context, state *framework.CycleState, p *v1.Pod, nodeName string) {
+	delete(p.Annotations, AnnoCpuset)
+}
+
+// socketUsage is the live accounting for one socket: which of its logical
+// CPUs are already consumed by previously-pinned pods, and how much of its
+// memory those pods already claim.
+type socketUsage struct {
+	usedCPUs     map[int]bool
+	usedMilliCpu int64
+	usedMemory   int64
+}
+
+// pickSocket implements the CPU accumulator: among sockets with enough free
+// CPUs and memory, it picks the one with the smallest sufficient free set
+// (best fit), and within that socket prefers whole free cores over orphan
+// hyperthread siblings, consuming reservation cpusets first when a matching
+// reservation selects this pod.
+func (plugin *NUMATopologyPlugin) pickSocket(nodeName string, nodeInfo *framework.NodeInfo, requestedMilliCpu int64, p *v1.Pod) (cpuset string, socket int, err error) {
+	if reservedCpuset, ok := plugin.matchReservation(nodeName, p); ok {
+		return reservedCpuset, -1, nil
+	}
+
+	topo, err := GetNodeNUMATopologyFromAnnotation(nodeInfo.Node())
+	if err != nil {
+		return "", -1, err
+	}
+	if topo == nil || len(topo.Sockets) == 0 {
+		return "", -1, fmt.Errorf("node %s has no NUMA topology info, cannot pin pod %s", nodeName, p.Name)
+	}
+
+	requestedMemory := requestedMemoryOf(p)
+	usageBySocket := socketUsageFromNodeInfo(topo, nodeInfo)
+
+	needCpus := (requestedMilliCpu + 999) / 1000 // round up to whole logical CPUs
+	freeMilliCpuBySocket := make([]int64, len(topo.Sockets))
+	freeMemoryBySocket := make([]int64, len(topo.Sockets))
+	for i, s := range topo.Sockets {
+		usage := usageBySocket[s.Socket]
+		freeMilliCpuBySocket[i] = int64(len(s.CPUs))*1000 - usage.usedMilliCpu
+		freeMemoryBySocket[i] = s.MemoryBytes - usage.usedMemory
+	}
+
+	// Best fit: try sockets in ascending order of free CPU, so the smallest
+	// sufficient socket is chosen first.
+	for _, i := range sortSocketsByFreeCpu(freeMilliCpuBySocket) {
+		s := topo.Sockets[i]
+		if freeMilliCpuBySocket[i] < needCpus*1000 || freeMemoryBySocket[i] < requestedMemory {
+			continue
+		}
+		chosen := pickCpusOnSocket(s, usageBySocket[s.Socket], int(needCpus))
+		if len(chosen) < int(needCpus) {
+			continue
+		}
+		return formatCpuset(chosen), s.Socket, nil
+	}
+
+	// No single socket suffices; try spanning sockets before giving up.
+	if cpuset, ok := spanSockets(topo, usageBySocket, needCpus, requestedMemory, freeMilliCpuBySocket, freeMemoryBySocket); ok {
+		return cpuset, -1, nil
+	}
+
+	return "", -1, fmt.Errorf("no NUMA socket (or combination of sockets) on node %s has %dm free CPU for pod %s", nodeName, requestedMilliCpu, p.Name)
+}
+
+// spanSockets is pickSocket's cross-socket fallback for a pod too large for
+// any single socket. It picks which sockets to use via
+// utils.SocketsForEvenSpread -- the same greedy "even spread" selection
+// utils.EvenSpreadSockets itself delegates to -- then assembles the actual
+// cpuset from each chosen socket via pickCpusOnSocket, which needs this
+// plugin's own concrete per-CPU NUMASocketTopology/socketUsage detail that
+// SocketsForEvenSpread's plain free-milliCPU view doesn't carry.
+func spanSockets(topo *NodeNUMATopology, usageBySocket map[int]*socketUsage, needCpus int64, requestedMemory int64, freeMilliCpuBySocket, freeMemoryBySocket []int64) (cpuset string, ok bool) {
+	chosen := utils.SocketsForEvenSpread(freeMilliCpuBySocket, needCpus*1000)
+
+	var freeMemory int64
+	var gotMilliCpu int64
+	for _, i := range chosen {
+		freeMemory += freeMemoryBySocket[i]
+		gotMilliCpu += freeMilliCpuBySocket[i]
+	}
+	if gotMilliCpu < needCpus*1000 || freeMemory < requestedMemory {
+		return "", false
+	}
+
+	var cpus []int
+	remaining := needCpus
+	for _, i := range chosen {
+		s := topo.Sockets[i]
+		take := freeMilliCpuBySocket[i] / 1000
+		if take > remaining {
+			take = remaining
+		}
+		picked := pickCpusOnSocket(s, usageBySocket[s.Socket], int(take))
+		if int64(len(picked)) < take {
+			return "", false
+		}
+		cpus = append(cpus, picked...)
+		remaining -= take
+	}
+	return formatCpuset(cpus), true
+}
+
+// socketUsageFromNodeInfo derives, for every socket, how much CPU and memory
+// its already-pinned pods (those carrying AnnoCpuset) have claimed. Each CPU
+// id is charged to the socket that owns it; a pod whose cpuset spans sockets
+// has its memory counted against the socket of its first CPU.
+func socketUsageFromNodeInfo(topo *NodeNUMATopology, nodeInfo *framework.NodeInfo) map[int]*socketUsage {
+	usage := make(map[int]*socketUsage, len(topo.Sockets))
+	cpuSocket := make(map[int]int)
+	for _, s := range topo.Sockets {
+		usage[s.Socket] = &socketUsage{usedCPUs: make(map[int]bool)}
+		for _, cpu := range s.CPUs {
+			cpuSocket[cpu.ID] = s.Socket
+		}
+	}
+
+	for _, podInfo := range nodeInfo.Pods {
+		pod := podInfo.Pod
+		cpuset, ok := pod.Annotations[AnnoCpuset]
+		if !ok || cpuset == "" {
+			continue
+		}
+		ids, err := ParseCpuset(cpuset)
+		if err != nil || len(ids) == 0 {
+			continue
+		}
+		for _, id := range ids {
+			if socketID, ok := cpuSocket[id]; ok {
+				usage[socketID].usedCPUs[id] = true
+				usage[socketID].usedMilliCpu += 1000
+			}
+		}
+		socketID, ok := cpuSocket[ids[0]]
+		if !ok {
+			continue
+		}
+		req, _ := resourcehelper.PodRequestsAndLimits(pod)
+		usage[socketID].usedMemory += req.Memory().Value()
+	}
+	return usage
+}
+
+// pickCpusOnSocket returns up to `need` free logical CPU ids on socket s,
+// preferring whole free physical cores (both hyperthread siblings idle) over
+// lone orphan siblings, so Guaranteed pods are not forced to share a core
+// with another pod's thread.
+func pickCpusOnSocket(s NUMASocketTopology, usage *socketUsage, need int) []int {
+	freeByCore := make(map[int][]int)
+	for _, cpu := range s.CPUs {
+		if usage.usedCPUs[cpu.ID] {
+			continue
+		}
+		freeByCore[cpu.CoreID] = append(freeByCore[cpu.CoreID], cpu.ID)
+	}
+
+	var fullCoreIDs, loneCoreIDs []int
+	for coreID, ids := range freeByCore {
+		if len(ids) >= 2 {
+			fullCoreIDs = append(fullCoreIDs, coreID)
+		} else {
+			loneCoreIDs = append(loneCoreIDs, coreID)
+		}
+	}
+	sort.Ints(fullCoreIDs)
+	sort.Ints(loneCoreIDs)
+
+	var ordered []int
+	for _, coreID := range fullCoreIDs {
+		ids := freeByCore[coreID]
+		sort.Ints(ids)
+		ordered = append(ordered, ids...)
+	}
+	for _, coreID := range loneCoreIDs {
+		ordered = append(ordered, freeByCore[coreID]...)
+	}
+
+	if len(ordered) > need {
+		ordered = ordered[:need]
+	}
+	return ordered
+}
+
+// matchReservation looks for a pre-claimed Reservation on nodeName whose
+// selector matches p, allowing the pod to consume from the reservation's
+// cpuset instead of going through the accumulator.
+func (plugin *NUMATopologyPlugin) matchReservation(nodeName string, p *v1.Pod) (cpuset string, ok bool) {
+	for _, r := range plugin.reservations[nodeName] {
+		if r.Spec.Selector == nil {
+			continue
+		}
+		if matchesLabels(r.Spec.Selector.MatchLabels, p.Labels) {
+			return r.Spec.Cpuset, true
+		}
+	}
+	return "", false
+}
+
+func matchesLabels(selector, labels map[string]string) bool {
+	for k, v := range selector {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func requestedMilliCpuOf(p *v1.Pod) int64 {
+	var total int64
+	for _, c := range p.Spec.Containers {
+		total += c.Resources.Requests.Cpu().MilliValue()
+	}
+	return total
+}
+
+func requestedMemoryOf(p *v1.Pod) int64 {
+	var total int64
+	for _, c := range p.Spec.Containers {
+		total += c.Resources.Requests.Memory().Value()
+	}
+	return total
+}
+
+// ParseCpuset parses a Linux cpuset string such as "0-1,4,6-7" into the list
+// of individual CPU ids it names. Exported so callers outside this package
+// (e.g. podresources/server.go, reporting AnnoCpuset over the PodResources
+// API) can parse it the same way pickSocket's own accounting does.
+func ParseCpuset(s string) ([]int, error) {
+	var ids []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if bounds := strings.SplitN(part, "-", 2); len(bounds) == 2 {
+			loI, err := strconv.Atoi(bounds[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid cpuset range %q: %w", part, err)
+			}
+			hiI, err := strconv.Atoi(bounds[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid cpuset range %q: %w", part, err)
+			}
+			for i := loI; i <= hiI; i++ {
+				ids = append(ids, i)
+			}
+			continue
+		}
+		id, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cpuset id %q: %w", part, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// formatCpuset renders a list of CPU ids as a sorted, comma-separated cpuset
+// string.
+func formatCpuset(ids []int) string {
+	sorted := append([]int(nil), ids...)
+	sort.Ints(sorted)
+	parts := make([]string, len(sorted))
+	for i, id := range sorted {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ",")
+}
+
+// sortSocketsByFreeCpu orders sockets ascending by free milliCPU, so the
+// accumulator can pick the smallest sufficient one (best fit).
+func sortSocketsByFreeCpu(freeMilliCpuBySocket []int64) []int {
+	idx := make([]int, len(freeMilliCpuBySocket))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(i, j int) bool {
+		return freeMilliCpuBySocket[idx[i]] < freeMilliCpuBySocket[idx[j]]
+	})
+	return idx
+}
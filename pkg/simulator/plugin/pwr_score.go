@@ -22,6 +22,7 @@ type PWRScorePlugin struct {
 
 // TODO: All the methods and functions should be in place. Now we need to bind the plugin to the scheduler framework, in the right places of the simulator.
 //       See FGD.
+// NOTE: registered under the Score extension point via Registry (see registry.go); no behavior change from the previous direct wiring.
 
 var _ framework.ScorePlugin = &PWRScorePlugin{} // This assignment is used at compile-time to check if the class implements the plugin interface.
 
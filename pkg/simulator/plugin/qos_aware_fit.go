@@ -0,0 +1,180 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	resourcehelper "k8s.io/kubectl/pkg/util/resource"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	simontype "github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/type"
+)
+
+// AnnoQoSLevel is the pod annotation that marks a pod as belonging to the
+// "reclaimed" QoS tier, on top of the three standard Kubernetes QoS classes.
+// NOTE: a pod lacking this annotation is classified using v1.Pod.Status.QOSClass
+// (BestEffort / Burstable / Guaranteed), as computed by the apiserver.
+const (
+	AnnoQoSLevel = "simon/qos-level"
+	QoSReclaimed = "reclaimed_cores"
+)
+
+// nodeResourcePool tracks the resources a single node can still hand out to a
+// given QoS tier. Regular pods (BestEffort/Burstable/Guaranteed) only consume
+// from regular, while reclaimed-tier pods only consume from reclaimed.
+type nodeResourcePool struct {
+	regularMilliCpu   int64
+	regularMemory     int64
+	reclaimedMilliCpu int64
+	reclaimedMemory   int64
+}
+
+// QoSAwareFitPlugin models Katalyst-style hybrid deployments: it keeps two
+// parallel resource pools per node (regular vs. reclaimed) and only allows a
+// reclaimed-tier pod to land on a node that has reclaimed headroom, i.e. idle
+// capacity not currently claimed by Guaranteed pods.
+type QoSAwareFitPlugin struct {
+	handle framework.Handle
+	pools  map[string]*nodeResourcePool
+}
+
+var _ framework.FilterPlugin = &QoSAwareFitPlugin{}
+var _ framework.ScorePlugin = &QoSAwareFitPlugin{}
+
+// NewQoSAwareFitPlugin instantiates the plugin. Pools are computed lazily, the
+// first time a node is seen, and refreshed on every Filter call so that
+// reclaimed headroom grows/shrinks as Guaranteed pods come and go.
+func NewQoSAwareFitPlugin(_ runtime.Object, handle framework.Handle) (framework.Plugin, error) {
+	return &QoSAwareFitPlugin{
+		handle: handle,
+		pools:  make(map[string]*nodeResourcePool),
+	}, nil
+}
+
+func (plugin *QoSAwareFitPlugin) Name() string {
+	return simontype.QoSAwareFitPluginName
+}
+
+// Filter rejects reclaimed-class pods when the node has no reclaimed headroom,
+// and rejects regular pods that do not fit the regular pool.
+func (plugin *QoSAwareFitPlugin) Filter(ctx context.Context, state *framework.CycleState, p *v1.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
+	node := nodeInfo.Node()
+	if node == nil {
+		return framework.NewStatus(framework.Error, "node not found")
+	}
+
+	pool := plugin.refreshPool(node, nodeInfo)
+	podReq, _ := resourcehelper.PodRequestsAndLimits(p)
+	milliCpu := podReq.Cpu().MilliValue()
+	memory := podReq.Memory().Value()
+
+	if isReclaimedPod(p) {
+		if milliCpu > pool.reclaimedMilliCpu || memory > pool.reclaimedMemory {
+			return framework.NewStatus(framework.Unschedulable, fmt.Sprintf("node(%s) has no reclaimed headroom for pod %s", node.Name, p.Name))
+		}
+		return framework.NewStatus(framework.Success)
+	}
+
+	if milliCpu > pool.regularMilliCpu || memory > pool.regularMemory {
+		return framework.NewStatus(framework.Unschedulable, fmt.Sprintf("node(%s) regular pool cannot fit pod %s", node.Name, p.Name))
+	}
+	return framework.NewStatus(framework.Success)
+}
+
+// Score prefers nodes that pack reclaimed pods onto hosts with the most idle
+// guaranteed capacity (i.e. the largest reclaimed pool), co-locating
+// best-effort/reclaimed workload where latency-sensitive pods leave headroom.
+// Regular pods are scored the opposite way, preferring nodes with less
+// reclaimed headroom so that "cold" hosts are saved for reclaimed colocation.
+func (plugin *QoSAwareFitPlugin) Score(ctx context.Context, state *framework.CycleState, p *v1.Pod, nodeName string) (int64, *framework.Status) {
+	nodeInfo, err := plugin.handle.SnapshotSharedLister().NodeInfos().Get(nodeName)
+	if err != nil || nodeInfo == nil || nodeInfo.Node() == nil {
+		return framework.MinNodeScore, framework.NewStatus(framework.Error, fmt.Sprintf("failed to get nodeInfo(%s)\n", nodeName))
+	}
+	pool := plugin.refreshPool(nodeInfo.Node(), nodeInfo)
+
+	allocatableMilliCpu := nodeInfo.Node().Status.Allocatable.Cpu().MilliValue()
+	if allocatableMilliCpu == 0 {
+		return framework.MinNodeScore, framework.NewStatus(framework.Success)
+	}
+	reclaimedRatio := float64(pool.reclaimedMilliCpu) / float64(allocatableMilliCpu)
+	if reclaimedRatio > 1 {
+		reclaimedRatio = 1
+	}
+	if reclaimedRatio < 0 {
+		reclaimedRatio = 0
+	}
+
+	if isReclaimedPod(p) {
+		return int64(reclaimedRatio * float64(framework.MaxNodeScore)), framework.NewStatus(framework.Success)
+	}
+	return int64((1 - reclaimedRatio) * float64(framework.MaxNodeScore)), framework.NewStatus(framework.Success)
+}
+
+func (plugin *QoSAwareFitPlugin) ScoreExtensions() framework.ScoreExtensions {
+	return nil
+}
+
+// refreshPool recomputes the regular/reclaimed split for a node from its
+// currently bound pods. The two tiers are tracked independently: the regular
+// budget is allocatable minus whatever regular-tier pods already use, and the
+// reclaimed budget is allocatable minus Guaranteed usage minus whatever
+// reclaimed-tier pods already use, so neither tier can be oversubscribed by
+// double-counting the other tier's placements.
+func (plugin *QoSAwareFitPlugin) refreshPool(node *v1.Node, nodeInfo *framework.NodeInfo) *nodeResourcePool {
+	allocatableMilliCpu := node.Status.Allocatable.Cpu().MilliValue()
+	allocatableMemory := node.Status.Allocatable.Memory().Value()
+
+	var guaranteedMilliCpu, guaranteedMemory int64
+	var regularUsedMilliCpu, regularUsedMemory int64
+	var reclaimedUsedMilliCpu, reclaimedUsedMemory int64
+	for _, podInfo := range nodeInfo.Pods {
+		pod := podInfo.Pod
+		req, _ := resourcehelper.PodRequestsAndLimits(pod)
+		milliCpu := req.Cpu().MilliValue()
+		memory := req.Memory().Value()
+		if isReclaimedPod(pod) {
+			reclaimedUsedMilliCpu += milliCpu
+			reclaimedUsedMemory += memory
+			continue
+		}
+		regularUsedMilliCpu += milliCpu
+		regularUsedMemory += memory
+		if pod.Status.QOSClass == v1.PodQOSGuaranteed {
+			guaranteedMilliCpu += milliCpu
+			guaranteedMemory += memory
+		}
+	}
+
+	pool := &nodeResourcePool{
+		regularMilliCpu:   allocatableMilliCpu - regularUsedMilliCpu,
+		regularMemory:     allocatableMemory - regularUsedMemory,
+		reclaimedMilliCpu: allocatableMilliCpu - guaranteedMilliCpu - reclaimedUsedMilliCpu,
+		reclaimedMemory:   allocatableMemory - guaranteedMemory - reclaimedUsedMemory,
+	}
+	plugin.pools[node.Name] = pool
+	return pool
+}
+
+func isReclaimedPod(pod *v1.Pod) bool {
+	return pod.Annotations[AnnoQoSLevel] == QoSReclaimed
+}
+
+// ColocationRatio reports, over a set of scheduled pods, the fraction that
+// were scheduled as reclaimed-tier, i.e. co-located onto guaranteed headroom.
+// Simulate() calls this after scheduling to print the report alongside the
+// existing GPU fragmentation numbers.
+func ColocationRatio(pods []*v1.Pod) float64 {
+	if len(pods) == 0 {
+		return 0
+	}
+	var reclaimed int
+	for _, pod := range pods {
+		if isReclaimedPod(pod) {
+			reclaimed++
+		}
+	}
+	return float64(reclaimed) / float64(len(pods))
+}
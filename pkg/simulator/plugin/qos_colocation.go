@@ -0,0 +1,211 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	resourcehelper "k8s.io/kubectl/pkg/util/resource"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/api/v1alpha1"
+	simontype "github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/type"
+)
+
+// LabelKoordQoSClass is the pod label Koordinator (v1.1.0) uses to mark a
+// pod's QoS tier for colocation. Simon only cares about the split it implies:
+// LS/LSR/SYSTEM pods get hard resource guarantees, BE pods may oversubscribe
+// a node's declared headroom and are the ones ColocationFitPlugin's
+// PostFilter evicts to make room for the others.
+const LabelKoordQoSClass = "koordinator.sh/qosClass"
+
+const (
+	KoordQoSLS     = "LS"
+	KoordQoSLSR    = "LSR"
+	KoordQoSBE     = "BE"
+	KoordQoSSystem = "SYSTEM"
+)
+
+// OvercommitRatios configures how far a node's BE pool may oversubscribe its
+// declared Allocatable/VG capacity. A ratio of 1.5 for CPU admits a BE pod as
+// long as the milliCPU requested by all pods on the node, of every tier and
+// including this one, stays within 1.5x Allocatable.
+type OvercommitRatios struct {
+	CPU    float64
+	Memory float64
+	VG     float64
+}
+
+// DefaultOvercommitRatios disables oversubscription: BE pods are bound by the
+// same Allocatable/VG capacity as every other tier until a caller opts in.
+var DefaultOvercommitRatios = OvercommitRatios{CPU: 1, Memory: 1, VG: 1}
+
+// overcommitRatiosFromConfig reads the BE-tier oversubscription ratios out of
+// profile.
+//
+// NOTE: v1alpha1.CustomConfig.SchedulerConfig is expected to carry an
+// OvercommitRatios field of the same CPU/Memory/VG float64 shape as this
+// package's own OvercommitRatios (populated from the Simon CR by the
+// scheduler config loader); it's a distinct v1alpha1 type rather than this
+// one to avoid v1alpha1 importing pkg/simulator/plugin. A zero value (the
+// field unset, or no override in the CR) falls back to
+// DefaultOvercommitRatios, matching NewColocationFitPlugin's own zero-value
+// handling.
+func overcommitRatiosFromConfig(profile v1alpha1.CustomConfig) OvercommitRatios {
+	cfg := profile.SchedulerConfig.OvercommitRatios
+	if (cfg == v1alpha1.OvercommitRatios{}) {
+		return DefaultOvercommitRatios
+	}
+	return OvercommitRatios{CPU: cfg.CPU, Memory: cfg.Memory, VG: cfg.VG}
+}
+
+// qosClassOf classifies pod using LabelKoordQoSClass, falling back to LS
+// (hard guarantees, no oversubscription) for pods that don't opt into
+// colocation.
+func qosClassOf(pod *v1.Pod) string {
+	if class, ok := pod.Labels[LabelKoordQoSClass]; ok {
+		return class
+	}
+	return KoordQoSLS
+}
+
+func isBEPod(pod *v1.Pod) bool {
+	return qosClassOf(pod) == KoordQoSBE
+}
+
+// ColocationFitPlugin models Koordinator-style colocation: LS/LSR/SYSTEM pods
+// are admitted against a node's plain Allocatable/VG capacity, while BE pods
+// are admitted against that capacity widened by OvercommitRatios. When an
+// LS/LSR/SYSTEM pod is rejected everywhere, PostFilter simulates Koordinator's
+// eviction controller by evicting BE pods off one rejecting node until the
+// pod would fit there.
+type ColocationFitPlugin struct {
+	handle  framework.Handle
+	ratios  OvercommitRatios
+	evicted map[string][]string
+}
+
+var _ framework.FilterPlugin = &ColocationFitPlugin{}
+var _ framework.PostFilterPlugin = &ColocationFitPlugin{}
+
+// NewColocationFitPlugin instantiates the plugin with the given overcommit
+// ratios; a zero OvercommitRatios falls back to DefaultOvercommitRatios.
+// ratios.VG isn't used by Filter/PostFilter below (they only gate CPU/
+// memory); newPluginByName reads it independently via
+// overcommitRatiosFromConfig when it builds the open-local ResourceHandler,
+// so this plugin no longer needs to hand it off anywhere itself.
+func NewColocationFitPlugin(_ runtime.Object, handle framework.Handle, ratios OvercommitRatios) (framework.Plugin, error) {
+	if (ratios == OvercommitRatios{}) {
+		ratios = DefaultOvercommitRatios
+	}
+	return &ColocationFitPlugin{handle: handle, ratios: ratios, evicted: make(map[string][]string)}, nil
+}
+
+func (plugin *ColocationFitPlugin) Name() string {
+	return simontype.ColocationFitPluginName
+}
+
+// Filter admits pod against node's regular budget (Allocatable, VG capacity),
+// widened by plugin.ratios when pod is BE-tier.
+func (plugin *ColocationFitPlugin) Filter(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
+	node := nodeInfo.Node()
+	if node == nil {
+		return framework.NewStatus(framework.Error, "node not found")
+	}
+
+	podReq, _ := resourcehelper.PodRequestsAndLimits(pod)
+	milliCpu := podReq.Cpu().MilliValue()
+	memory := podReq.Memory().Value()
+
+	allocatableMilliCpu := node.Status.Allocatable.Cpu().MilliValue()
+	allocatableMemory := node.Status.Allocatable.Memory().Value()
+	var usedMilliCpu, usedMemory int64
+	for _, podInfo := range nodeInfo.Pods {
+		req, _ := resourcehelper.PodRequestsAndLimits(podInfo.Pod)
+		usedMilliCpu += req.Cpu().MilliValue()
+		usedMemory += req.Memory().Value()
+	}
+
+	cpuBudget := allocatableMilliCpu
+	memBudget := allocatableMemory
+	if isBEPod(pod) {
+		cpuBudget = int64(float64(allocatableMilliCpu) * plugin.ratios.CPU)
+		memBudget = int64(float64(allocatableMemory) * plugin.ratios.Memory)
+	}
+
+	if usedMilliCpu+milliCpu > cpuBudget {
+		return framework.NewStatus(framework.Unschedulable, fmt.Sprintf("node(%s) has no %s-tier cpu headroom for pod %s", node.Name, qosClassOf(pod), pod.Name))
+	}
+	if usedMemory+memory > memBudget {
+		return framework.NewStatus(framework.Unschedulable, fmt.Sprintf("node(%s) has no %s-tier memory headroom for pod %s", node.Name, qosClassOf(pod), pod.Name))
+	}
+	return framework.NewStatus(framework.Success)
+}
+
+// PostFilter runs once pod (expected to be LS/LSR/SYSTEM; BE pods aren't
+// eviction-eligible and are turned away immediately) has been rejected by
+// every node. It walks the rejecting nodes looking for one whose BE pods,
+// evicted newest-first, would free enough CPU/memory for pod to fit, and
+// nominates that node -- a stand-in for actually re-running Filter post
+// eviction, matching this simulator's level of fidelity for PreEnqueue/
+// PostBind hooks elsewhere in this package.
+func (plugin *ColocationFitPlugin) PostFilter(ctx context.Context, state *framework.CycleState, pod *v1.Pod, filteredNodeStatusMap framework.NodeToStatusMap) (*framework.PostFilterResult, *framework.Status) {
+	if isBEPod(pod) {
+		return nil, framework.NewStatus(framework.Unschedulable, "BE pods are not eligible for eviction-based preemption")
+	}
+
+	podReq, _ := resourcehelper.PodRequestsAndLimits(pod)
+	milliCpu := podReq.Cpu().MilliValue()
+	memory := podReq.Memory().Value()
+
+	nodeInfos, err := plugin.handle.SnapshotSharedLister().NodeInfos().List()
+	if err != nil {
+		return nil, framework.AsStatus(err)
+	}
+
+	for _, nodeInfo := range nodeInfos {
+		node := nodeInfo.Node()
+		if node == nil {
+			continue
+		}
+		if _, rejected := filteredNodeStatusMap[node.Name]; !rejected {
+			continue
+		}
+
+		var usedMilliCpu, usedMemory int64
+		for _, podInfo := range nodeInfo.Pods {
+			req, _ := resourcehelper.PodRequestsAndLimits(podInfo.Pod)
+			usedMilliCpu += req.Cpu().MilliValue()
+			usedMemory += req.Memory().Value()
+		}
+		shortMilliCpu := usedMilliCpu + milliCpu - node.Status.Allocatable.Cpu().MilliValue()
+		shortMemory := usedMemory + memory - node.Status.Allocatable.Memory().Value()
+
+		var toEvict []string
+		for i := len(nodeInfo.Pods) - 1; i >= 0 && (shortMilliCpu > 0 || shortMemory > 0); i-- {
+			bePod := nodeInfo.Pods[i].Pod
+			if !isBEPod(bePod) {
+				continue
+			}
+			req, _ := resourcehelper.PodRequestsAndLimits(bePod)
+			shortMilliCpu -= req.Cpu().MilliValue()
+			shortMemory -= req.Memory().Value()
+			toEvict = append(toEvict, bePod.Name)
+		}
+		if shortMilliCpu > 0 || shortMemory > 0 {
+			continue
+		}
+
+		plugin.evicted[node.Name] = append(plugin.evicted[node.Name], toEvict...)
+		return framework.NewPostFilterResultWithNominatedNode(node.Name), framework.NewStatus(framework.Success)
+	}
+	return nil, framework.NewStatus(framework.Unschedulable, fmt.Sprintf("no node has enough BE pods to evict for pod %s/%s", pod.Namespace, pod.Name))
+}
+
+// EvictedBEPods reports, per node, the BE pods PostFilter has evicted so far
+// to admit an LS/LSR/SYSTEM pod. Simulate() does not print it; its report
+// shows ColocationRatio only.
+func (plugin *ColocationFitPlugin) EvictedBEPods() map[string][]string {
+	return plugin.evicted
+}
@@ -0,0 +1,177 @@
+package plugin
+
+import (
+	"fmt"
+
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/api/v1alpha1"
+	simontype "github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/type"
+)
+
+// ExtensionPoint names one stage of the Kubernetes scheduling framework, in
+// the order the simulator's scheduling loop (called from RunCluster) invokes
+// them.
+type ExtensionPoint string
+
+const (
+	PreEnqueueExtensionPoint ExtensionPoint = "PreEnqueue"
+	QueueSortExtensionPoint  ExtensionPoint = "QueueSort"
+	PreFilterExtensionPoint  ExtensionPoint = "PreFilter"
+	FilterExtensionPoint     ExtensionPoint = "Filter"
+	PostFilterExtensionPoint ExtensionPoint = "PostFilter"
+	PreScoreExtensionPoint   ExtensionPoint = "PreScore"
+	ScoreExtensionPoint      ExtensionPoint = "Score"
+	ReserveExtensionPoint    ExtensionPoint = "Reserve"
+	PermitExtensionPoint     ExtensionPoint = "Permit"
+	PreBindExtensionPoint    ExtensionPoint = "PreBind"
+	BindExtensionPoint       ExtensionPoint = "Bind"
+	PostBindExtensionPoint   ExtensionPoint = "PostBind"
+)
+
+// orderedExtensionPoints lists every extension point in scheduling order, so
+// the loop in RunCluster can walk them without hard-coding the sequence twice.
+var orderedExtensionPoints = []ExtensionPoint{
+	PreEnqueueExtensionPoint,
+	QueueSortExtensionPoint,
+	PreFilterExtensionPoint,
+	FilterExtensionPoint,
+	PostFilterExtensionPoint,
+	PreScoreExtensionPoint,
+	ScoreExtensionPoint,
+	ReserveExtensionPoint,
+	PermitExtensionPoint,
+	PreBindExtensionPoint,
+	BindExtensionPoint,
+	PostBindExtensionPoint,
+}
+
+// PreEnqueuePlugin gates a pod from entering the active scheduling queue
+// until its dependencies are met (e.g. a PodGroup's minMember, or a held
+// ElasticQuota admission). It mirrors framework.PreEnqueuePlugin.
+type PreEnqueuePlugin interface {
+	framework.Plugin
+	PreEnqueue(p *framework.QueuedPodInfo) *framework.Status
+}
+
+// PostBindPlugin runs after a pod has been bound, for bookkeeping that must
+// see the final placement (e.g. updating per-cohort usage, NUMA caches).
+type PostBindPlugin interface {
+	framework.Plugin
+	PostBind(p *framework.QueuedPodInfo, nodeName string)
+}
+
+// Registry keeps every enabled plugin indexed by the extension point(s) it
+// implements, so the scheduling loop can fetch "all PreFilter plugins", "all
+// Score plugins", etc. without type-switching the whole plugin list on every
+// pod.
+type Registry struct {
+	plugins map[ExtensionPoint][]framework.Plugin
+}
+
+// NewRegistry builds a Registry from a slice of instantiated plugins,
+// registering each one under every extension point interface it satisfies.
+func NewRegistry(plugins ...framework.Plugin) *Registry {
+	r := &Registry{plugins: make(map[ExtensionPoint][]framework.Plugin)}
+	for _, p := range plugins {
+		r.register(p)
+	}
+	return r
+}
+
+func (r *Registry) register(p framework.Plugin) {
+	if _, ok := p.(PreEnqueuePlugin); ok {
+		r.add(PreEnqueueExtensionPoint, p)
+	}
+	if _, ok := p.(framework.QueueSortPlugin); ok {
+		r.add(QueueSortExtensionPoint, p)
+	}
+	if _, ok := p.(framework.PreFilterPlugin); ok {
+		r.add(PreFilterExtensionPoint, p)
+	}
+	if _, ok := p.(framework.FilterPlugin); ok {
+		r.add(FilterExtensionPoint, p)
+	}
+	if _, ok := p.(framework.PostFilterPlugin); ok {
+		r.add(PostFilterExtensionPoint, p)
+	}
+	if _, ok := p.(framework.PreScorePlugin); ok {
+		r.add(PreScoreExtensionPoint, p)
+	}
+	if _, ok := p.(framework.ScorePlugin); ok {
+		r.add(ScoreExtensionPoint, p)
+	}
+	if _, ok := p.(framework.ReservePlugin); ok {
+		r.add(ReserveExtensionPoint, p)
+	}
+	if _, ok := p.(framework.PermitPlugin); ok {
+		r.add(PermitExtensionPoint, p)
+	}
+	if _, ok := p.(framework.PreBindPlugin); ok {
+		r.add(PreBindExtensionPoint, p)
+	}
+	if _, ok := p.(framework.BindPlugin); ok {
+		r.add(BindExtensionPoint, p)
+	}
+	if _, ok := p.(PostBindPlugin); ok {
+		r.add(PostBindExtensionPoint, p)
+	}
+}
+
+func (r *Registry) add(point ExtensionPoint, p framework.Plugin) {
+	r.plugins[point] = append(r.plugins[point], p)
+}
+
+// At returns the plugins enabled for a given extension point, in the order
+// they were registered (for NewRegistryFromConfig, the order listed in
+// profile.SchedulerConfig.Plugins).
+func (r *Registry) At(point ExtensionPoint) []framework.Plugin {
+	return r.plugins[point]
+}
+
+// NewRegistryFromConfig instantiates only the plugins named in profile, in
+// the configured order, for each extension point. Plugin names must have been
+// registered through a constructor known to this package (PWRScorePlugin,
+// QoSAwareFitPlugin, NUMATopologyPlugin, ...); unknown names are an error so
+// that typos in a profile's YAML fail fast instead of silently no-op'ing.
+// registry is this run's NodeStateRegistry (see node_state.go), built fresh
+// per simulator.Simulate call and handed to whichever plugins key state off
+// it (BalancedAllocationScorePlugin/MostAllocatedScorePlugin, via the
+// open-local ResourceHandler) instead of sharing one process-wide registry
+// across concurrent runs.
+func NewRegistryFromConfig(profile v1alpha1.CustomConfig, handle framework.Handle, registry *NodeStateRegistry) (*Registry, error) {
+	enabled := make([]framework.Plugin, 0, len(profile.SchedulerConfig.Plugins))
+	for _, name := range profile.SchedulerConfig.Plugins {
+		p, err := newPluginByName(name, handle, profile, registry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build plugin %q: %w", name, err)
+		}
+		enabled = append(enabled, p)
+	}
+	return NewRegistry(enabled...), nil
+}
+
+func newPluginByName(name string, handle framework.Handle, profile v1alpha1.CustomConfig, registry *NodeStateRegistry) (framework.Plugin, error) {
+	switch name {
+	case simontype.PWRScorePluginName:
+		return NewPWDScorePlugin(nil, handle, nil)
+	case simontype.QoSAwareFitPluginName:
+		return NewQoSAwareFitPlugin(nil, handle)
+	case simontype.NUMATopologyPluginName:
+		return NewNUMATopologyPlugin(nil, handle, nil)
+	case simontype.GpuTopologyScorePluginName:
+		return NewGpuTopologyScorePlugin(nil, handle)
+	case simontype.BalancedAllocationScorePluginName:
+		return NewBalancedAllocationScorePlugin(nil, handle, resourceWeightsFromConfig(profile), RegisteredResourceHandlerNames(), registry, overcommitRatiosFromConfig(profile).VG)
+	case simontype.MostAllocatedScorePluginName:
+		return NewMostAllocatedScorePlugin(nil, handle, resourceWeightsFromConfig(profile), RegisteredResourceHandlerNames(), registry, overcommitRatiosFromConfig(profile).VG)
+	case simontype.ColocationFitPluginName:
+		return NewColocationFitPlugin(nil, handle, overcommitRatiosFromConfig(profile))
+	case simontype.CompositeScorePluginName:
+		return NewCompositeScorePlugin(nil, handle, compositeScoreStrategiesFromConfig(profile), nil)
+	case simontype.GangPreEnqueuePluginName:
+		return NewGangPreEnqueuePlugin()
+	default:
+		return nil, fmt.Errorf("unknown plugin name %q", name)
+	}
+}
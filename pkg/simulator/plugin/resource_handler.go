@@ -0,0 +1,323 @@
+package plugin
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pquerna/ffjson/ffjson"
+	v1 "k8s.io/api/core/v1"
+
+	gpushareutils "github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/type/open-gpu-share/utils"
+	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/utils"
+)
+
+// ResourceHandler predicates and scores a node for a pod w.r.t. one extended
+// resource (open-local VGs, nvidia.com/gpu, a vendor accelerator, ...),
+// replacing the hardcoded "open-local"/"gpu" string checks previously
+// scattered across pkg/apply's report()/satisfyResourceSetting()/sweep.go.
+type ResourceHandler interface {
+	// Name is the extended-resource name as it appears in Options.ExtendedResources
+	// (e.g. "open-local", "gpu").
+	Name() string
+	// Fit reports whether node can satisfy pod's request of this resource.
+	Fit(node *v1.Node, pod *v1.Pod) (bool, string, error)
+	// Score ranks node for pod on a [0, 100] scale; callers that don't care
+	// about this resource's score can ignore the return value.
+	Score(node *v1.Node, pod *v1.Pod) int64
+}
+
+// ResourceHandlerFactory builds a ResourceHandler against registry (the
+// caller's run-scoped NodeStateRegistry, see node_state.go) and
+// vgOvercommitRatio (this run's ColocationFitPlugin.OvercommitRatios.VG,
+// see qos_colocation.go), mirroring the pluginFactory/FactoryAdapter pattern
+// in k8s.io/kubernetes/pkg/scheduler/framework/plugins/registry.go. A factory
+// whose handler doesn't need either is free to ignore it.
+type ResourceHandlerFactory func(registry *NodeStateRegistry, vgOvercommitRatio float64) ResourceHandler
+
+// resourceHandlerFactories is the process-wide registry of known extended
+// resources, populated by RegisterResourceHandler (the built-ins below
+// register themselves via init()).
+var resourceHandlerFactories = map[string]ResourceHandlerFactory{}
+
+// RegisterResourceHandler adds factory under name, so a user can plug in a
+// handler for RDMA, hugepages, or a vendor accelerator without touching this
+// package. Re-registering an existing name overwrites it, matching how the
+// upstream scheduler treats repeated plugin registration.
+func RegisterResourceHandler(name string, factory ResourceHandlerFactory) {
+	resourceHandlerFactories[name] = factory
+}
+
+// NewResourceHandler builds the handler registered under name against
+// registry and vgOvercommitRatio, or an error if name isn't a known extended
+// resource.
+func NewResourceHandler(name string, registry *NodeStateRegistry, vgOvercommitRatio float64) (ResourceHandler, error) {
+	factory, ok := resourceHandlerFactories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown extended resource handler %q", name)
+	}
+	return factory(registry, vgOvercommitRatio), nil
+}
+
+// HasResourceHandler reports whether name is both requested via
+// extendedResources and has a registered handler, replacing the old
+// containLocalStorage/containGpu string checks.
+func HasResourceHandler(extendedResources []string, name string) bool {
+	if _, ok := resourceHandlerFactories[name]; !ok {
+		return false
+	}
+	for _, res := range extendedResources {
+		if res == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisteredResourceHandlerNames returns every extended-resource name with a
+// handler registered via RegisterResourceHandler (e.g. "open-local", "gpu"),
+// sorted for deterministic iteration. Callers like
+// BalancedAllocationScorePlugin use this so they score every known extended
+// resource without having to enumerate them by hand.
+func RegisteredResourceHandlerNames() []string {
+	names := make([]string, 0, len(resourceHandlerFactories))
+	for name := range resourceHandlerFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	RegisterResourceHandler("open-local", func(registry *NodeStateRegistry, vgOvercommitRatio float64) ResourceHandler {
+		return &openLocalHandler{registry: registry, vgOvercommitRatio: vgOvercommitRatio}
+	})
+	RegisterResourceHandler("gpu", func(registry *NodeStateRegistry, _ float64) ResourceHandler {
+		return &gpuResourceHandler{registry: registry}
+	})
+}
+
+// nodeStateKeyVGStorage is this package's Others key (see node_state.go) for
+// a node's parsed AnnoNodeLocalStorage.
+const nodeStateKeyVGStorage = "open-local-storage"
+
+// vgStorageCache pairs a parsed utils.NodeStorage with the raw annotation
+// string it came from, so nodeStorageForNode can tell whether node's
+// annotation has since changed instead of needing an explicit invalidation
+// call from whoever last patched it.
+type vgStorageCache struct {
+	raw     string
+	storage utils.NodeStorage
+}
+
+// nodeStorageForNode parses node's AnnoNodeLocalStorage annotation, caching
+// the result in node's NodeState.Others (within registry) so repeated
+// Fit/Score calls within the same scheduling cycle don't re-unmarshal the
+// same JSON. The cache self-invalidates: it's keyed on the raw annotation
+// string, so a changed annotation (a different *v1.Node object for the same
+// name, or the annotation patched in place) is simply re-parsed.
+// NodeState.Get/Set take NodeState's own lock, so concurrent callers sharing
+// a node name within the same registry can't race on the underlying map.
+func nodeStorageForNode(registry *NodeStateRegistry, node *v1.Node) (utils.NodeStorage, bool, error) {
+	nodeStorageStr, exist := node.Annotations[AnnoNodeLocalStorage]
+	if !exist {
+		return utils.NodeStorage{}, false, nil
+	}
+	state := registry.GetOrCreate(node.Name)
+	if cached, ok := state.Get(nodeStateKeyVGStorage); ok && cached.(vgStorageCache).raw == nodeStorageStr {
+		return cached.(vgStorageCache).storage, true, nil
+	}
+	var nodeStorage utils.NodeStorage
+	if err := ffjson.Unmarshal([]byte(nodeStorageStr), &nodeStorage); err != nil {
+		return utils.NodeStorage{}, false, fmt.Errorf("error when unmarshal json data, node is %s: %v", node.Name, err)
+	}
+	state.Set(nodeStateKeyVGStorage, vgStorageCache{raw: nodeStorageStr, storage: nodeStorage})
+	return nodeStorage, true, nil
+}
+
+// openLocalHandler wraps the open-local VG occupancy check previously inlined
+// in pkg/apply's satisfyResourceSetting. registry is this handler's
+// run-scoped NodeStateRegistry, set when NewResourceHandler builds it.
+// vgOvercommitRatio is this run's ColocationFitPlugin.OvercommitRatios.VG,
+// threaded through NewResourceHandler instead of a package-level var so that
+// two sweep cases building distinct handlers from distinct configs (see
+// pkg/apply/sweep.go) can't race on each other's ratio.
+type openLocalHandler struct {
+	registry          *NodeStateRegistry
+	vgOvercommitRatio float64
+}
+
+func (h *openLocalHandler) Name() string { return "open-local" }
+
+func (h *openLocalHandler) Fit(node *v1.Node, pod *v1.Pod) (bool, string, error) {
+	volumes := utils.GetPodStorage(pod)
+	if volumes == nil {
+		return true, "", nil
+	}
+	nodeStorage, exist, err := nodeStorageForNode(h.registry, node)
+	if err != nil {
+		return false, "", err
+	}
+	if !exist {
+		return false, fmt.Sprintf("node %s has no local storage annotation", node.Name), nil
+	}
+	ratio := 1.0
+	if isBEPod(pod) {
+		ratio = h.vgOvercommitRatio
+	}
+	for _, volume := range volumes.Volumes {
+		var fits bool
+		for _, vg := range nodeStorage.VGs {
+			if int64(float64(vg.Capacity)*ratio)-vg.Requested >= volume.Size {
+				fits = true
+				break
+			}
+		}
+		if !fits {
+			return false, fmt.Sprintf("node %s has no VG with %d bytes free for volume %s", node.Name, volume.Size, volume.Kind), nil
+		}
+	}
+	return true, "", nil
+}
+
+func (h *openLocalHandler) Score(node *v1.Node, pod *v1.Pod) int64 {
+	nodeStorage, exist, err := nodeStorageForNode(h.registry, node)
+	if err != nil || !exist {
+		return 0
+	}
+	var capacity, requested int64
+	for _, vg := range nodeStorage.VGs {
+		capacity += vg.Capacity
+		requested += vg.Requested
+	}
+	if capacity == 0 {
+		return 0
+	}
+	return 100 - requested*100/capacity
+}
+
+// gpuResourceHandler wraps the nvidia.com/gpu fit/score previously inlined
+// across pkg/apply and pkg/simulator/plugin (see pwr_score.go, podresources/server.go).
+// registry is this handler's run-scoped NodeStateRegistry (see node_state.go),
+// set when NewResourceHandler builds it, so the per-device split gpuDevicesForNode
+// derives from AnnoNodeGpuBrief survives across Fit/Score calls instead of
+// being rebuilt from scratch every time (see gpuDevicesForNode).
+type gpuResourceHandler struct {
+	registry *NodeStateRegistry
+}
+
+func (h *gpuResourceHandler) Name() string { return "gpu" }
+
+// milliGpuLeftFromGpuNodeInfo splits a node's aggregate free milli-gpu
+// (AnnoNodeGpuBrief only reports GpuCount/GpuUsedMilli in total, not
+// per-device) evenly across gpuCount devices, so gpuResourceHandler can drive
+// GPUAllocator/DevicesFromMilliGpuLeftList the same way a node with a real
+// per-device breakdown would.
+func milliGpuLeftFromGpuNodeInfo(gpuCount int, gpuUsedMilli int64) []int64 {
+	left := make([]int64, gpuCount)
+	perDeviceUsed := gpuUsedMilli / int64(gpuCount)
+	remainder := gpuUsedMilli % int64(gpuCount)
+	for i := range left {
+		used := perDeviceUsed
+		if int64(i) < remainder {
+			used++
+		}
+		left[i] = gpushareutils.MILLI - used
+	}
+	return left
+}
+
+// nodeStateKeyGPUBrief is this package's Others key (see node_state.go) for a
+// node's gpuBriefCache.
+const nodeStateKeyGPUBrief = "gpu-devices-from-brief"
+
+// gpuBriefCache pairs a per-device split built by milliGpuLeftFromGpuNodeInfo
+// with the AnnoNodeGpuBrief fields it was derived from, so gpuDevicesForNode
+// can tell whether the node's aggregate usage has changed since it was last
+// built instead of discarding and re-splitting the devices map (and whatever
+// per-device allocations AllocateGPU/ReleaseGPU have since recorded against
+// it) on every Fit/Score call.
+type gpuBriefCache struct {
+	gpuCount     int
+	gpuUsedMilli int64
+	devices      map[string]*DeviceInfo
+}
+
+// gpuDevicesForNode returns node's per-device split, rebuilding (and evenly
+// re-splitting) it only when gpuCount/gpuUsedMilli have changed since the
+// cached build. AnnoNodeGpuBrief still only reports a node-wide total, so a
+// freshly (re)built split is still a uniform division of that total across
+// devices -- this does not by itself give gpuResourceHandler real per-device
+// fragmentation. What it does fix is the previous behavior of throwing the
+// split away and rebuilding it from scratch on every single call: as long as
+// gpuCount/gpuUsedMilli are unchanged, repeated calls now see the very same
+// *DeviceInfo values, so once something in the scheduling loop calls
+// AllocateGPU/ReleaseGPU against this cached map (neither is wired into a
+// Reserve/Bind path in this tree yet), those per-device assignments will
+// persist across calls instead of being discarded by the next Fit/Score's
+// even split.
+func gpuDevicesForNode(registry *NodeStateRegistry, node *v1.Node, gpuCount int, gpuUsedMilli int64) map[string]*DeviceInfo {
+	state := registry.GetOrCreate(node.Name)
+	if cached, ok := state.Get(nodeStateKeyGPUBrief); ok {
+		c := cached.(gpuBriefCache)
+		if c.gpuCount == gpuCount && c.gpuUsedMilli == gpuUsedMilli {
+			return c.devices
+		}
+	}
+	devices := DevicesFromMilliGpuLeftList(node.Name, milliGpuLeftFromGpuNodeInfo(gpuCount, gpuUsedMilli))
+	state.Set(nodeStateKeyGPUBrief, gpuBriefCache{gpuCount: gpuCount, gpuUsedMilli: gpuUsedMilli, devices: devices})
+	return devices
+}
+
+// Fit checks pod's request through GPUAllocator against node's cached
+// per-device split (see gpuDevicesForNode), so a multi-GPU pod can be
+// rejected for fragmentation even when the node's aggregate free milli-gpu
+// would otherwise suffice.
+func (h *gpuResourceHandler) Fit(node *v1.Node, pod *v1.Pod) (bool, string, error) {
+	gn, err := utils.GetGpuNodeInfoFromAnnotation(node)
+	if err != nil {
+		return false, "", err
+	}
+	requested := gpushareutils.GetGpuMilliFromPodAnnotation(pod) * int64(gpushareutils.GetGpuCountFromPodAnnotation(pod))
+	if requested == 0 {
+		return true, "", nil
+	}
+	if gn == nil || gn.GpuCount == 0 {
+		return false, fmt.Sprintf("node %s has no GPUs", node.Name), nil
+	}
+
+	podRes := utils.GetPodResource(pod)
+	if podRes.MigProfile != "" {
+		// milliGpuLeftFromGpuNodeInfo never puts a device into MIG mode (MIG
+		// geometry isn't recoverable from AnnoNodeGpuBrief), so routing MIG
+		// requests through GPUAllocator here would reject every one of them;
+		// keep the old aggregate milli-gpu check for MIG pods instead.
+		capacity := int64(gn.GpuCount) * gpushareutils.MILLI
+		if gn.GpuUsedMilli+requested > capacity {
+			return false, fmt.Sprintf("node %s has insufficient gpu-milli for pod %s/%s", node.Name, pod.Namespace, pod.Name), nil
+		}
+		return true, "", nil
+	}
+
+	devices := gpuDevicesForNode(h.registry, node, int(gn.GpuCount), gn.GpuUsedMilli)
+	if err := NewGPUAllocator(devices, nil).Fit(podRes); err != nil {
+		return false, err.Error(), nil
+	}
+	return true, "", nil
+}
+
+// Score goes through GPUAllocator.DeviceUtilization over the same cached
+// per-device split Fit checks against (see gpuDevicesForNode), so the two
+// share one source of truth instead of each re-deriving their own.
+func (h *gpuResourceHandler) Score(node *v1.Node, pod *v1.Pod) int64 {
+	gn, err := utils.GetGpuNodeInfoFromAnnotation(node)
+	if err != nil || gn == nil || gn.GpuCount == 0 {
+		return 0
+	}
+	devices := gpuDevicesForNode(h.registry, node, int(gn.GpuCount), gn.GpuUsedMilli)
+	util := NewGPUAllocator(devices, nil).DeviceUtilization()
+	var sum float64
+	for _, u := range util {
+		sum += u
+	}
+	return 100 - int64(sum/float64(len(util))*100)
+}
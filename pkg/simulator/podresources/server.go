@@ -0,0 +1,151 @@
+// Package podresources serves the final simulated cluster state over a gRPC
+// endpoint modeled on Kubelet's PodResources API, so external tooling can
+// consume it the same way it would a real kubelet.
+package podresources
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	corev1 "k8s.io/api/core/v1"
+	podresourcesapi "k8s.io/kubelet/pkg/apis/podresources/v1"
+
+	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/simulator/plugin"
+	simontype "github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/type"
+	"github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/utils"
+)
+
+// Server implements podresourcesapi.PodResourcesListerServer over a fixed
+// snapshot of simulator.SimulateResult.NodeStatus, taken once at Simulate()
+// return time.
+type Server struct {
+	podresourcesapi.UnimplementedPodResourcesListerServer
+	nodeStatuses []simontype.NodeStatus
+}
+
+func NewServer(nodeStatuses []simontype.NodeStatus) *Server {
+	return &Server{nodeStatuses: nodeStatuses}
+}
+
+// Serve starts listening on socketPath (a unix socket, matching kubelet's own
+// PodResources endpoint) and blocks until the listener is closed.
+func Serve(socketPath string, nodeStatuses []simontype.NodeStatus) error {
+	if err := os.RemoveAll(socketPath); err != nil {
+		return err
+	}
+	lis, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return err
+	}
+
+	grpcServer := grpc.NewServer()
+	podresourcesapi.RegisterPodResourcesListerServer(grpcServer, NewServer(nodeStatuses))
+
+	log.Infof("podresources: serving simulated cluster state on %s\n", socketPath)
+	return grpcServer.Serve(lis)
+}
+
+// List returns one PodResources entry per pod in the simulated cluster,
+// mirroring what a real kubelet reports for CPU, memory and device (GPU)
+// assignments.
+func (s *Server) List(ctx context.Context, req *podresourcesapi.ListPodResourcesRequest) (*podresourcesapi.ListPodResourcesResponse, error) {
+	var pods []*podresourcesapi.PodResources
+	for _, status := range s.nodeStatuses {
+		for _, pod := range status.Pods {
+			if pod.Spec.NodeName != status.Node.Name {
+				continue
+			}
+			pods = append(pods, podResourcesOf(pod))
+		}
+	}
+	return &podresourcesapi.ListPodResourcesResponse{PodResources: pods}, nil
+}
+
+// GetAllocatableResources reports one nvidia.com/gpu device entry per GPU of
+// every node (ID "<node>-<index>"), from the node's GPU annotation. CpuIds
+// and Memory are left empty, and GPU-milli and local storage VGs are not
+// reported.
+func (s *Server) GetAllocatableResources(ctx context.Context, req *podresourcesapi.AllocatableResourcesRequest) (*podresourcesapi.AllocatableResourcesResponse, error) {
+	var devices []*podresourcesapi.ContainerDevices
+	for _, status := range s.nodeStatuses {
+		node := status.Node
+		if gn, err := utils.GetGpuNodeInfoFromAnnotation(node); err == nil && gn != nil {
+			for idx := 0; idx < gn.GpuCount; idx++ {
+				devices = append(devices, &podresourcesapi.ContainerDevices{
+					ResourceName: "nvidia.com/gpu",
+					DeviceIds:    []string{fmt.Sprintf("%s-%d", node.Name, idx)},
+				})
+			}
+		}
+	}
+	return &podresourcesapi.AllocatableResourcesResponse{Devices: devices}, nil
+}
+
+// podResourcesOf reports each container's assigned CPU ids, memory, and GPU
+// devices. AnnoCpuset/AnnoGpuIndex are recorded at the pod level (NUMATopologyPlugin
+// pins a whole pod to a cpuset, AllocateGPU assigns a whole pod's GPU
+// devices), so every container in the pod is reported with the same
+// cpuIds/devices; per-container Memory comes from that container's own
+// request, the one resource kubelet's real PodResources API also reports
+// per-container.
+func podResourcesOf(pod *corev1.Pod) *podresourcesapi.PodResources {
+	cpuIds := cpuIdsFromAnnotation(pod)
+	devices := gpuDevicesFromAnnotation(pod)
+
+	var containers []*podresourcesapi.ContainerResources
+	for _, c := range pod.Spec.Containers {
+		containers = append(containers, &podresourcesapi.ContainerResources{
+			Name:   c.Name,
+			CpuIds: cpuIds,
+			Memory: []*podresourcesapi.ContainerMemory{{
+				MemoryType: string(corev1.ResourceMemory),
+				Size_:      uint64(c.Resources.Requests.Memory().Value()),
+			}},
+			Devices: devices,
+		})
+	}
+	return &podresourcesapi.PodResources{
+		Name:       pod.Name,
+		Namespace:  pod.Namespace,
+		Containers: containers,
+	}
+}
+
+// cpuIdsFromAnnotation returns the CPU ids NUMATopologyPlugin.Reserve pinned
+// pod to via plugin.AnnoCpuset, or nil for a pod that was never NUMA-pinned
+// (BestEffort/Burstable QoS, or NUMATopologyPlugin not enabled in this run's
+// profile).
+func cpuIdsFromAnnotation(pod *corev1.Pod) []int64 {
+	cpuset, ok := pod.Annotations[plugin.AnnoCpuset]
+	if !ok || cpuset == "" {
+		return nil
+	}
+	ids, err := plugin.ParseCpuset(cpuset)
+	if err != nil {
+		log.Warnf("podresources: pod %s/%s has unparseable %s annotation %q: %v\n", pod.Namespace, pod.Name, plugin.AnnoCpuset, cpuset, err)
+		return nil
+	}
+	cpuIds := make([]int64, len(ids))
+	for i, id := range ids {
+		cpuIds[i] = int64(id)
+	}
+	return cpuIds
+}
+
+// gpuDevicesFromAnnotation returns the device(s) AllocateGPU recorded against
+// pod via plugin.AnnoGpuIndex, or nil for a pod that wasn't assigned any GPU.
+func gpuDevicesFromAnnotation(pod *corev1.Pod) []*podresourcesapi.ContainerDevices {
+	deviceIDs, ok := pod.Annotations[plugin.AnnoGpuIndex]
+	if !ok || deviceIDs == "" {
+		return nil
+	}
+	return []*podresourcesapi.ContainerDevices{{
+		ResourceName: "nvidia.com/gpu",
+		DeviceIds:    strings.Split(deviceIDs, ","),
+	}}
+}
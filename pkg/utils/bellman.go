@@ -0,0 +1,222 @@
+package utils
+
+import (
+	"runtime"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	simontype "github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/type"
+)
+
+// ValueIterationConfig tunes the solver added alongside NodeGpuFragBellman:
+// unlike the memoized recursion, it runs synchronous sweeps over an
+// explicitly enumerated state space and surfaces the greedy policy, not just
+// the expected fragmentation value.
+type ValueIterationConfig struct {
+	Epsilon float64 // prune successor states with cumProb*gpuMilliLeftTotal below this
+	Delta   float64 // terminal cutoff: states with frag ratio >= delta stop expanding
+	Gamma   float64 // discount factor, < 1 so the solver converges without relying on Delta
+	Tol     float64 // convergence tolerance on max_s |V_{k+1}(s) - V_k(s)|
+	MaxIter int
+}
+
+// stateNode is one entry of the enumerated reachable state space: the
+// NodeResource itself, its memoization key, and the transition probabilities
+// (one per typical pod) to its successor states.
+type stateNode struct {
+	res          simontype.NodeResource
+	key          string
+	transitions  []transition
+}
+
+type transition struct {
+	prob     float64
+	podRes   simontype.PodResource
+	nextKey  string
+}
+
+// ValueIterationResult holds the converged value function and the greedy
+// policy extracted from it, plus solver statistics for logging.
+type ValueIterationResult struct {
+	Values     map[string]float64
+	Policy     map[string]simontype.PodResource // state key -> pod that best "covers" the remaining capacity
+	Iterations int
+	StateCount int
+	Residual   float64
+}
+
+// SolveValueIteration enumerates the state space reachable from root by
+// repeatedly subtracting typical pods, then runs synchronous value-iteration
+// sweeps to convergence, extracting a greedy packing-hint policy at the end.
+// The BFS records each state once, keyed by its Flatten string, so repeated
+// states are expanded only once; each sweep is parallelized across states
+// using a worker pool that collects per-state results in a sync.Map.
+func SolveValueIteration(root simontype.NodeResource, typicalPods simontype.TargetPodList, cfg ValueIterationConfig) ValueIterationResult {
+	if cfg.Gamma <= 0 || cfg.Gamma >= 1 {
+		cfg.Gamma = 0.99
+	}
+	if cfg.Tol <= 0 {
+		cfg.Tol = 1e-3
+	}
+	if cfg.MaxIter <= 0 {
+		cfg.MaxIter = 1000
+	}
+
+	states := enumerateStates(root, typicalPods, cfg)
+	log.Infof("SolveValueIteration: enumerated %d reachable states from root(%s)\n", len(states), root.Repr())
+
+	values := make(map[string]float64, len(states))
+	for key := range states {
+		values[key] = 0
+	}
+
+	var residual float64
+	iter := 0
+	for ; iter < cfg.MaxIter; iter++ {
+		next, sweepResidual := sweep(states, values, cfg.Gamma)
+		values = next
+		residual = sweepResidual
+		if residual < cfg.Tol {
+			iter++
+			break
+		}
+	}
+
+	policy := extractPolicy(states, values)
+	log.Infof("SolveValueIteration: converged after %d iterations, residual=%.6f, |S|=%d\n", iter, residual, len(states))
+
+	return ValueIterationResult{
+		Values:     values,
+		Policy:     policy,
+		Iterations: iter,
+		StateCount: len(states),
+		Residual:   residual,
+	}
+}
+
+// enumerateStates performs a BFS from root, generating s' = s.Sub(podRes) for
+// every typical pod, bounded by cumProb*gpuMilliLeftTotal >= epsilon, and
+// marking states with frag ratio >= delta as terminal (no further expansion).
+func enumerateStates(root simontype.NodeResource, typicalPods simontype.TargetPodList, cfg ValueIterationConfig) map[string]*stateNode {
+	states := make(map[string]*stateNode)
+	type queueItem struct {
+		res     simontype.NodeResource
+		cumProb float64
+	}
+	queue := []queueItem{{res: root, cumProb: 1.0}}
+
+	for len(queue) > 0 {
+		item := queue[0]
+		queue = queue[1:]
+
+		key := item.res.Flatten("value-iteration")
+		if _, visited := states[key]; visited {
+			continue
+		}
+		node := &stateNode{res: item.res, key: key}
+		states[key] = node
+
+		gpuMilliLeftTotal := float64(GetGpuMilliLeftTotal(item.res))
+		if gpuMilliLeftTotal*item.cumProb < cfg.Epsilon {
+			continue // terminal: too unlikely to matter
+		}
+		fragRatio := NodeGpuFragRatio(item.res, typicalPods)
+		if fragRatio.FragRatioSumExceptQ3() >= cfg.Delta {
+			continue // terminal: already fully fragmented
+		}
+
+		for _, pod := range typicalPods {
+			nextRes, err := item.res.Sub(pod.TargetPodResource)
+			if err != nil {
+				continue
+			}
+			nextKey := nextRes.Flatten("value-iteration")
+			node.transitions = append(node.transitions, transition{prob: pod.Percentage, podRes: pod.TargetPodResource, nextKey: nextKey})
+			queue = append(queue, queueItem{res: nextRes, cumProb: item.cumProb * pod.Percentage})
+		}
+	}
+	return states
+}
+
+// sweep runs one synchronous value-iteration update V_{k+1}(s) = sum_p
+// p(pod) * gamma * V_k(s'), parallelized across states by a bounded pool of
+// runtime.GOMAXPROCS(0) workers pulling state keys off a channel; a terminal
+// state's reward is its own GPU-milli-left total. Per-state results are
+// collected in a sync.Map (rather than a plain map behind one mutex) since
+// workers key off disjoint state keys and gain nothing from serializing on a
+// single lock. It returns the updated value map and max_s |V_{k+1}(s) -
+// V_k(s)|.
+func sweep(states map[string]*stateNode, values map[string]float64, gamma float64) (map[string]float64, float64) {
+	keys := make(chan string, len(states))
+	for key := range states {
+		keys <- key
+	}
+	close(keys)
+
+	var next sync.Map
+	var mu sync.Mutex // guards residual only; updates are independent otherwise
+	var residual float64
+	var wg sync.WaitGroup
+
+	workerCount := runtime.GOMAXPROCS(0)
+	if workerCount > len(states) {
+		workerCount = len(states)
+	}
+	for w := 0; w < workerCount; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range keys {
+				node := states[key]
+				var v float64
+				if len(node.transitions) == 0 {
+					v = float64(GetGpuMilliLeftTotal(node.res))
+				} else {
+					for _, t := range node.transitions {
+						v += t.prob * gamma * values[t.nextKey]
+					}
+				}
+
+				next.Store(key, v)
+				diff := v - values[key]
+				if diff < 0 {
+					diff = -diff
+				}
+				mu.Lock()
+				if diff > residual {
+					residual = diff
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	out := make(map[string]float64, len(states))
+	next.Range(func(k, v interface{}) bool {
+		out[k.(string)] = v.(float64)
+		return true
+	})
+	return out, residual
+}
+
+// extractPolicy picks, for every non-terminal state, the typical pod whose
+// successor state has the highest converged value -- i.e. the pod that best
+// "covers" the node's remaining capacity, exposed as a packing hint.
+func extractPolicy(states map[string]*stateNode, values map[string]float64) map[string]simontype.PodResource {
+	policy := make(map[string]simontype.PodResource, len(states))
+	for key, node := range states {
+		if len(node.transitions) == 0 {
+			continue
+		}
+		best := node.transitions[0]
+		for _, t := range node.transitions[1:] {
+			if values[t.nextKey] > values[best.nextKey] {
+				best = t
+			}
+		}
+		policy[key] = best.podRes
+	}
+	return policy
+}
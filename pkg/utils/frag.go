@@ -22,19 +22,67 @@ const (
 	XLSatisfied = "xl_satisfied"
 	XRLackCPU   = "xr_lack_cpu"
 	NoAccess    = "no_access"
+	// Q5CrossNUMA marks a pod that only fits a node when its CPU/memory/GPU
+	// demand is split across more than one NUMA socket (see GetNodePodFragNUMA).
+	Q5CrossNUMA = "q5_cross_numa"
+	// Q2LackMigGeometry marks a pod requesting a MIG profile for which the
+	// node has free GPU milli-capacity but no compatible partition layout left.
+	Q2LackMigGeometry = "q2_lack_mig_geometry"
 )
 
+// MigProfile names an NVIDIA Multi-Instance GPU partition size, e.g. on an
+// A100-40GB: "1g.5gb", "2g.10gb", "3g.20gb", "4g.20gb", "7g.40gb".
+type MigProfile string
+
+const (
+	Mig1g5gb  MigProfile = "1g.5gb"
+	Mig2g10gb MigProfile = "2g.10gb"
+	Mig3g20gb MigProfile = "3g.20gb"
+	Mig4g20gb MigProfile = "4g.20gb"
+	Mig7g40gb MigProfile = "7g.40gb"
+)
+
+// migGPCCost is how many of a GPU's 7 GPU Compute (GPC) slices a profile
+// consumes. Only the count is modelled: where on the die the used GPCs lie is
+// not tracked, so placement conflicts between slices are not detected.
+var migGPCCost = map[MigProfile]int{
+	Mig1g5gb:  1,
+	Mig2g10gb: 2,
+	Mig3g20gb: 3,
+	Mig4g20gb: 4,
+	Mig7g40gb: 7,
+}
+
+// MigPartitionTree tracks, for a single GPU, how many GPCs (out of 7 on
+// A100/H100) are still free to carve a new MIG instance from.
+type MigPartitionTree struct {
+	FreeGPCs int
+}
+
+// CanAllocateMigProfile reports whether profile can still be carved out of
+// tree: true when the profile's GPC cost is at most tree.FreeGPCs. Whether
+// the free GPCs are contiguous is not checked.
+func (tree MigPartitionTree) CanAllocateMigProfile(profile MigProfile) bool {
+	cost, ok := migGPCCost[profile]
+	if !ok {
+		return false
+	}
+	return tree.FreeGPCs >= cost
+}
+
 var FragRatioDataMap = map[string]int{
-	Q1LackBoth:  0,
-	Q2LackGpu:   1,
-	Q3Satisfied: 2,
-	Q4LackCpu:   3,
-	XLSatisfied: 4,
-	XRLackCPU:   5,
-	NoAccess:    6,
+	Q1LackBoth:        0,
+	Q2LackGpu:         1,
+	Q3Satisfied:       2,
+	Q4LackCpu:         3,
+	XLSatisfied:       4,
+	XRLackCPU:         5,
+	NoAccess:          6,
+	Q5CrossNUMA:       7,
+	Q2LackMigGeometry: 8,
 }
 
-var GpuNumTypeList = []string{"PureCpu", "ShareGpu", "OneGpu", "TwoGpu", "FourGpu", "EightGpu", "Others"}
+var GpuNumTypeList = []string{"PureCpu", "ShareGpu", "OneGpu", "TwoGpu", "FourGpu", "EightGpu", "MigGpu", "Others"}
 
 type FragRatio struct {
 	Data []float64
@@ -221,6 +269,114 @@ func GetFragAmountByNodeResAndFragRatio(nodeRes simontype.NodeResource, fragRati
 	return fragAmount
 }
 
+// NodeNUMAFragAmount reports, for the whole node, how its idle CPU
+// (nodeRes.MilliCpuLeft) splits across frag types when each typical pod is
+// classified by GetNodePodFragNUMA, weighted by the pod's frequency
+// (similarly to how NodeGpuShareFragAmount treats leftover GPU milli-cores as
+// fragment). It returns one FragAmount for the node, not one per socket. It
+// mirrors the GPU frag report so Simulate() can print both side by side.
+//
+// NOTE: depends on simontype.NodeResource carrying a NUMASockets field (see
+// chunk0-3/chunk1-1); each socket is expected to expose its own MilliCpuLeft.
+func NodeNUMAFragAmount(nodeRes simontype.NodeResource, typicalPods simontype.TargetPodList) FragAmount {
+	data := make([]float64, len(FragRatioDataMap))
+	fragAmount := NewFragAmount(nodeRes.NodeName, data)
+
+	for _, pod := range typicalPods {
+		freq := pod.Percentage
+		if freq < 0 || freq > 1 {
+			log.Errorf("pod %v has bad freq: %f\n", pod.TargetPodResource, freq)
+			continue
+		}
+		// A pod is satisfied only if some single socket can host its full CPU
+		// demand; otherwise its share counts as cross-NUMA fragmentation.
+		fragType := GetNodePodFragNUMA(nodeRes, pod.TargetPodResource)
+		fragAmount.AddByFragType(fragType, freq*float64(nodeRes.MilliCpuLeft))
+	}
+	return fragAmount
+}
+
+// GetNodePodFragNUMA is the per-socket counterpart of GetNodePodFrag: a pod is
+// Q3Satisfied only if a single NUMA socket can host all of its CPU, memory
+// and GPU demand at once. When the node as a whole has enough of each
+// resource but no single socket does, the pod is classified Q5CrossNUMA
+// instead of Q3Satisfied, since running it would require splitting its
+// request across sockets (with the cross-socket memory latency that implies).
+//
+// GPU capacity is not split per socket: every socket is checked against the
+// node's whole MilliGpuLeftList, and leftover GPU milli-cores are not
+// attributed to any socket, so a socket whose CPUs cannot host the pod yields
+// Q5CrossNUMA (not Q2LackGpu) whenever the node as a whole would fit it.
+func GetNodePodFragNUMA(nodeRes simontype.NodeResource, podRes simontype.PodResource) string {
+	if len(nodeRes.NUMASockets) == 0 {
+		// Node has no NUMA topology info: fall back to the node-wide classifier.
+		return GetNodePodFrag(nodeRes, podRes)
+	}
+
+	for _, socket := range nodeRes.NUMASockets {
+		if socket.MilliCpuLeft >= podRes.MilliCpu && socket.MemoryLeft >= podRes.Memory && CanNodeHostPodOnGpuMemory(nodeRes, podRes) {
+			return Q3Satisfied
+		}
+	}
+
+	// No single socket satisfies the pod; if the node-wide view would have
+	// satisfied it, the pod only fits by spanning sockets.
+	if GetNodePodFrag(nodeRes, podRes) == Q3Satisfied {
+		return Q5CrossNUMA
+	}
+	return GetNodePodFrag(nodeRes, podRes)
+}
+
+// EvenSpreadSockets picks, for a pod that must span sockets, the subset of
+// NUMASockets minimizing the maximum resulting per-socket CPU utilization
+// (greedy bin-packing), mirroring Koordinator's "even spread" NUMA policy.
+// It returns the indices (into nodeRes.NUMASockets) chosen to host the pod.
+func EvenSpreadSockets(nodeRes simontype.NodeResource, podRes simontype.PodResource) (chosen []int) {
+	freeMilliCpuBySocket := make([]int64, len(nodeRes.NUMASockets))
+	for i, s := range nodeRes.NUMASockets {
+		freeMilliCpuBySocket[i] = s.MilliCpuLeft
+	}
+	return SocketsForEvenSpread(freeMilliCpuBySocket, podRes.MilliCpu)
+}
+
+// SocketsForEvenSpread is EvenSpreadSockets' selection policy -- sockets
+// ordered by free milliCPU descending, consumed from the most-free socket
+// first until neededMilliCpu is covered -- generalized over a plain
+// []int64 of per-socket free milliCPU instead of simontype.NodeResource's
+// NUMASockets, so callers with a different socket representation (e.g.
+// plugin.NUMATopologyPlugin's concrete per-CPU NUMASocketTopology, which
+// needs per-CPU/CoreID detail NodeResource.NUMASockets doesn't carry) can
+// reuse the same even-spread policy instead of duplicating it. It returns
+// the indices into freeMilliCpuBySocket chosen to host the pod.
+func SocketsForEvenSpread(freeMilliCpuBySocket []int64, neededMilliCpu int64) (chosen []int) {
+	type socketUtil struct {
+		idx  int
+		free int64
+	}
+	sockets := make([]socketUtil, len(freeMilliCpuBySocket))
+	for i, free := range freeMilliCpuBySocket {
+		sockets[i] = socketUtil{idx: i, free: free}
+	}
+	sort.Slice(sockets, func(i, j int) bool { return sockets[i].free > sockets[j].free })
+
+	remaining := neededMilliCpu
+	for _, s := range sockets {
+		if remaining <= 0 {
+			break
+		}
+		take := s.free
+		if take > remaining {
+			take = remaining
+		}
+		if take <= 0 {
+			continue
+		}
+		chosen = append(chosen, s.idx)
+		remaining -= take
+	}
+	return chosen
+}
+
 func GetGpuMilliLeftTotal(nodeRes simontype.NodeResource) (gpuMilliLeftTotal int64) {
 	for _, gpuMilliLeft := range nodeRes.MilliGpuLeftList {
 		gpuMilliLeftTotal += gpuMilliLeft
@@ -308,23 +464,25 @@ func GetTypicalPods(allPods []*v1.Pod, config v1alpha1.TypicalPodsConfig) simont
 		}
 		total += weightedCnt
 
-		switch tgtPodRes.GpuNumber {
-		case 0:
+		switch {
+		case tgtPodRes.GpuNumber == 0:
 			podGpuCntMap[GpuNumTypeList[0]] += 1 // CPU
-		case 1:
+		case tgtPodRes.MigProfile != "":
+			podGpuCntMap[GpuNumTypeList[6]] += 1 // MigGpu
+		case tgtPodRes.GpuNumber == 1:
 			if tgtPodRes.MilliGpu < gpushareutils.MILLI {
 				podGpuCntMap[GpuNumTypeList[1]] += 1 // ShareGpu
 			} else {
 				podGpuCntMap[GpuNumTypeList[2]] += 1 // OneGpu
 			}
-		case 2:
+		case tgtPodRes.GpuNumber == 2:
 			podGpuCntMap[GpuNumTypeList[3]] += 1 // TwoGpu
-		case 4:
+		case tgtPodRes.GpuNumber == 4:
 			podGpuCntMap[GpuNumTypeList[4]] += 1 // FourGpu
-		case 8:
+		case tgtPodRes.GpuNumber == 8:
 			podGpuCntMap[GpuNumTypeList[5]] += 1 // EightGpu
 		default:
-			podGpuCntMap[GpuNumTypeList[6]] += 1 // Others
+			podGpuCntMap[GpuNumTypeList[7]] += 1 // Others
 		}
 	}
 
@@ -379,6 +537,23 @@ func GetTypicalPods(allPods []*v1.Pod, config v1alpha1.TypicalPodsConfig) simont
 	}
 }
 
+// NodeNUMAFragCurve computes the per-socket fragmentation curve of a node
+// against typicalPods: one FragAmount per NUMA socket, obtained from a copy
+// of nodeRes whose MilliCpuLeft is replaced by the socket's; the node's
+// memory and whole GPU list are kept as they are. This lets callers
+// compare NUMA placement policies the same way NodeGpuShareFragAmount lets
+// them compare GPU placement policies.
+func NodeNUMAFragCurve(nodeRes simontype.NodeResource, typicalPods simontype.TargetPodList) []FragAmount {
+	curve := make([]FragAmount, 0, len(nodeRes.NUMASockets))
+	for i, socket := range nodeRes.NUMASockets {
+		socketNodeRes := nodeRes
+		socketNodeRes.NodeName = fmt.Sprintf("%s-socket%d", nodeRes.NodeName, i)
+		socketNodeRes.MilliCpuLeft = socket.MilliCpuLeft
+		curve = append(curve, NodeGpuShareFragAmount(socketNodeRes, typicalPods))
+	}
+	return curve
+}
+
 func GetSkylinePods(allPods []*v1.Pod) (skylinePods simontype.SkylinePodList) {
 	skylinePods = make([]simontype.PodResource, 0)
 	podResList := make([]simontype.PodResource, 0)
@@ -445,6 +620,10 @@ func SortTargetPodInDecreasingCount(tgtPodResMap map[simontype.PodResource]float
 }
 
 func CanNodeHostPodOnGpuMemory(nodeRes simontype.NodeResource, podRes simontype.PodResource) bool {
+	if podRes.MigProfile != "" {
+		return CanNodeHostMigProfile(nodeRes, MigProfile(podRes.MigProfile))
+	}
+
 	gpuRequest := podRes.GpuNumber
 	for _, gpuHostMem := range nodeRes.MilliGpuLeftList {
 		if gpuHostMem >= podRes.MilliGpu {
@@ -457,6 +636,18 @@ func CanNodeHostPodOnGpuMemory(nodeRes simontype.NodeResource, podRes simontype.
 	return false
 }
 
+// CanNodeHostMigProfile reports whether any GPU on the node still has a MIG
+// partition tree with enough free GPCs to carve out profile, respecting the
+// geometric constraint captured by MigPartitionTree.CanAllocateMigProfile.
+func CanNodeHostMigProfile(nodeRes simontype.NodeResource, profile MigProfile) bool {
+	for _, tree := range nodeRes.MigPartitionTrees {
+		if tree.CanAllocateMigProfile(profile) {
+			return true
+		}
+	}
+	return false
+}
+
 func GetNodePodFrag(nodeRes simontype.NodeResource, podRes simontype.PodResource) string {
 
 	// Case 1 - pod does not require GPU resources (XL and XR cases).
@@ -473,6 +664,12 @@ func GetNodePodFrag(nodeRes simontype.NodeResource, podRes simontype.PodResource
 		return NoAccess
 	}
 
+	// Case 2.5 - pod requests a MIG profile, node has free GPU milli-capacity
+	// overall but no device with a compatible MIG partition layout left.
+	if podRes.MigProfile != "" && GetGpuMilliLeftTotal(nodeRes) >= podRes.MilliGpu && !CanNodeHostMigProfile(nodeRes, MigProfile(podRes.MigProfile)) {
+		return Q2LackMigGeometry
+	}
+
 	// Case 3 - node has enough GPU resources to host the pod.
 	if CanNodeHostPodOnGpuMemory(nodeRes, podRes) {
 		// Case 3.1 - node has enough CPU resources to host the pod (Q3).
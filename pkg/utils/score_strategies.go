@@ -0,0 +1,113 @@
+package utils
+
+import (
+	simontype "github.com/hkust-adsl/kubernetes-scheduler-simulator/pkg/type"
+)
+
+// maxStrategyScore mirrors the kube-scheduler convention of scoring every
+// strategy on a [0, 100] scale before composing them.
+const maxStrategyScore = 100
+
+// NodeScoreStrategies composes the paper's fragmentation metric with classic
+// Kubernetes node-resource scoring strategies, so users can A/B test them
+// against NodeGpuShareFragAmountScore on the same nodes.
+type NodeScoreStrategies struct {
+	// Weights for the composite score: finalScore = WeightFrag*(100-fragScore)
+	// + WeightLeastRequested*leastReq + WeightMostRequested*mostReq +
+	// WeightRequestedToCapacityRatio*rtc. Configured via v1alpha1.
+	WeightFrag                     float64
+	WeightLeastRequested           float64
+	WeightMostRequested            float64
+	WeightRequestedToCapacityRatio float64
+	// Shape is the user-supplied piecewise-linear utilization->score function
+	// for RequestedToCapacityRatio; nil falls back to a linear least-requested
+	// curve, matching the upstream plugin's default.
+	Shape func(utilizationPercent int64) int64
+}
+
+// NodeAllocatable is the per-resource capacity/already-requested pair a
+// scoring strategy needs; nodeRes alone only tracks what is left, not what
+// the node started with, so callers (the Score plugin, which also has the
+// corev1.Node) pass allocatable in explicitly.
+type NodeAllocatable struct {
+	AllocatableMilliCpu int64
+	RequestedMilliCpu   int64
+	AllocatableMemory   int64
+	RequestedMemory     int64
+}
+
+// LeastRequested mirrors the upstream least-requested priority:
+// ((capacity - requested) * maxStrategyScore / capacity), averaged over CPU,
+// memory and aggregate GPU-milli.
+func LeastRequested(alloc NodeAllocatable, nodeRes simontype.NodeResource, podRes simontype.PodResource) int64 {
+	return averageOverResources(alloc, nodeRes, podRes, func(capacity, requested int64) int64 {
+		if capacity == 0 {
+			return 0
+		}
+		return (capacity - requested) * maxStrategyScore / capacity
+	})
+}
+
+// MostRequested is LeastRequested's inverted form, preferring nodes that are
+// already heavily utilized, for bin-packing experiments.
+func MostRequested(alloc NodeAllocatable, nodeRes simontype.NodeResource, podRes simontype.PodResource) int64 {
+	return averageOverResources(alloc, nodeRes, podRes, func(capacity, requested int64) int64 {
+		if capacity == 0 {
+			return 0
+		}
+		return requested * maxStrategyScore / capacity
+	})
+}
+
+// RequestedToCapacityRatio scores a node via a user-supplied piecewise-linear
+// shape function over post-allocation utilization, mirroring the upstream
+// plugin of the same name.
+func RequestedToCapacityRatio(alloc NodeAllocatable, nodeRes simontype.NodeResource, podRes simontype.PodResource, shape func(utilizationPercent int64) int64) int64 {
+	if shape == nil {
+		shape = func(utilizationPercent int64) int64 { return maxStrategyScore - utilizationPercent }
+	}
+	return averageOverResources(alloc, nodeRes, podRes, func(capacity, requested int64) int64 {
+		if capacity == 0 {
+			return shape(0)
+		}
+		utilizationPercent := requested * 100 / capacity
+		if utilizationPercent > 100 {
+			utilizationPercent = 100
+		}
+		return shape(utilizationPercent)
+	})
+}
+
+// averageOverResources applies scoreFn to (capacity, requested-after-pod) for
+// CPU, memory, and aggregate GPU-milli, and returns the unweighted average.
+func averageOverResources(alloc NodeAllocatable, nodeRes simontype.NodeResource, podRes simontype.PodResource, scoreFn func(capacity, requested int64) int64) int64 {
+	gpuCapacity := int64(len(nodeRes.MilliGpuLeftList)) * 1000
+	gpuRequested := gpuCapacity - GetGpuMilliLeftTotal(nodeRes) + podRes.MilliGpu
+
+	scores := []int64{
+		scoreFn(alloc.AllocatableMilliCpu, alloc.RequestedMilliCpu+podRes.MilliCpu),
+		scoreFn(alloc.AllocatableMemory, alloc.RequestedMemory+podRes.Memory),
+		scoreFn(gpuCapacity, gpuRequested),
+	}
+	var sum int64
+	for _, s := range scores {
+		sum += s
+	}
+	return sum / int64(len(scores))
+}
+
+// CompositeScore blends the fragmentation metric with the classic strategies
+// per the configured weights, letting NodeGpuShareFragAmountScore act as one
+// term among several instead of the sole scoring signal.
+func (s NodeScoreStrategies) CompositeScore(alloc NodeAllocatable, nodeRes simontype.NodeResource, podRes simontype.PodResource, typicalPods simontype.TargetPodList) float64 {
+	fragScore := NodeGpuShareFragAmountScore(nodeRes, typicalPods)
+	normalizedFragScore := fragScore
+	if normalizedFragScore > maxStrategyScore {
+		normalizedFragScore = maxStrategyScore
+	}
+
+	return s.WeightFrag*(maxStrategyScore-normalizedFragScore) +
+		s.WeightLeastRequested*float64(LeastRequested(alloc, nodeRes, podRes)) +
+		s.WeightMostRequested*float64(MostRequested(alloc, nodeRes, podRes)) +
+		s.WeightRequestedToCapacityRatio*float64(RequestedToCapacityRatio(alloc, nodeRes, podRes, s.Shape))
+}